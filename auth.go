@@ -0,0 +1,176 @@
+package http
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	stdhttp "net/http"
+	"sync"
+	"time"
+)
+
+// BasicAuth is an option to authenticate requests with a username and password,
+// replacing any existing Authorization header
+func BasicAuth(username, password string) SetHeaderOption {
+	credentials := base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+	return SetHeader("Authorization", "Basic "+credentials)
+}
+
+// BearerToken is an option to authenticate requests with a bearer token,
+// replacing any existing Authorization header
+func BearerToken(token string) SetHeaderOption {
+	return SetHeader("Authorization", "Bearer "+token)
+}
+
+// Token is an access token used to authenticate requests
+type Token struct {
+	AccessToken string
+	// TokenType is the authorization scheme of the token, Bearer if empty
+	TokenType string
+	// Expiry is when the token expires, or zero if it does not
+	Expiry time.Time
+}
+
+// expiryDelta is how long before its expiry a token is refreshed,
+// so that it does not expire while a request is in flight
+const expiryDelta = 10 * time.Second
+
+func (t *Token) valid() bool {
+	return t != nil && t.AccessToken != "" && (t.Expiry.IsZero() || time.Until(t.Expiry) > expiryDelta)
+}
+
+func (t *Token) header() string {
+	if t.TokenType == "" {
+		return "Bearer " + t.AccessToken
+	}
+	return t.TokenType + " " + t.AccessToken
+}
+
+// TokenFetcher fetches new access tokens
+type TokenFetcher interface {
+	FetchToken(ctx context.Context) (*Token, error)
+}
+
+// TokenFetcherFunc is a function implementing TokenFetcher
+type TokenFetcherFunc func(ctx context.Context) (*Token, error)
+
+func (f TokenFetcherFunc) FetchToken(ctx context.Context) (*Token, error) {
+	return f(ctx)
+}
+
+type TokenSourceOption struct {
+	source *tokenSource
+}
+
+// TokenSource is an option to authenticate requests with tokens from the fetcher.
+// The token is fetched when the first request is sent, and kept until shortly before it expires.
+// Concurrent requests share a single fetch, which is not cancelled with any one of them.
+//
+// If a request is rejected with 401 Unauthorized, a new token is fetched and the request is re-sent once,
+// as long as its body can be replayed. The cached token is shared by all the clients the option is applied to
+func TokenSource(fetcher TokenFetcher) TokenSourceOption {
+	return TokenSourceOption{&tokenSource{fetcher: fetcher}}
+}
+
+func (o TokenSourceOption) ModifyRequest(r *Request) error {
+	r.tokens = o.source
+	return nil
+}
+
+func (o TokenSourceOption) ModifyClient(c *Client) {
+	PreRequestMiddlewares(o).ModifyClient(c)
+}
+
+type tokenSource struct {
+	fetcher TokenFetcher
+
+	mu    sync.Mutex
+	token *Token
+	// fetch is the fetch in flight, if any
+	fetch *tokenFetch
+}
+
+type tokenFetch struct {
+	done  chan struct{}
+	token *Token
+	err   error
+}
+
+// tokenFetchTimeout limits how long a token fetch can take, as it is not tied to any one request
+const tokenFetchTimeout = time.Minute
+
+// get returns a valid token, fetching a new one if the cached token is the stale one.
+// The fetch is shared with concurrent callers, each of which only stops waiting for it when its own ctx is done
+func (s *tokenSource) get(ctx context.Context, stale *Token) (*Token, error) {
+	s.mu.Lock()
+	if s.token != stale && s.token.valid() {
+		token := s.token
+		s.mu.Unlock()
+		return token, nil
+	}
+	fetch := s.fetch
+	if fetch == nil {
+		fetch = &tokenFetch{done: make(chan struct{})}
+		s.fetch = fetch
+		go s.run(fetch)
+	}
+	s.mu.Unlock()
+
+	select {
+	case <-fetch.done:
+		return fetch.token, fetch.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (s *tokenSource) run(fetch *tokenFetch) {
+	ctx, cancel := context.WithTimeout(context.Background(), tokenFetchTimeout)
+	defer cancel()
+	fetch.token, fetch.err = s.fetcher.FetchToken(ctx)
+
+	s.mu.Lock()
+	if fetch.err == nil {
+		s.token = fetch.token
+	}
+	s.fetch = nil
+	s.mu.Unlock()
+	close(fetch.done)
+}
+
+// do sends the request with a token, re-sending it with a new token if it is rejected
+func (s *tokenSource) do(next Doer, req *stdhttp.Request) (*stdhttp.Response, error) {
+	ctx := req.Context()
+	token, err := s.get(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	authed := req.Clone(ctx)
+	authed.Header.Set("Authorization", token.header())
+	resp, err := next.Do(authed)
+	if err != nil || resp.StatusCode != stdhttp.StatusUnauthorized || !canResend(req) {
+		return resp, err
+	}
+
+	// the token may have been revoked
+	if token, err = s.get(ctx, token); err != nil {
+		return resp, nil
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	_ = resp.Body.Close()
+
+	authed = req.Clone(ctx)
+	authed.Header.Set("Authorization", token.header())
+	if req.GetBody != nil {
+		if authed.Body, err = req.GetBody(); err != nil {
+			return nil, err
+		}
+	}
+	return next.Do(authed)
+}
+
+// canResend reports whether the body of the request can be replayed
+func canResend(req *stdhttp.Request) bool {
+	return req.Body == nil || req.Body == stdhttp.NoBody || req.GetBody != nil
+}
@@ -0,0 +1,167 @@
+package http_test
+
+import (
+	"context"
+	"fmt"
+	stdhttp "net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/conradludgate/go-http"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// authorized responds like Echo, rejecting requests that don't use the valid token
+func authorized(valid *atomic.Value) httpmock.Responder {
+	return func(req *stdhttp.Request) (*stdhttp.Response, error) {
+		auth := req.Header.Values("Authorization")
+		if token, _ := valid.Load().(string); token != "" && (len(auth) != 1 || auth[0] != "Bearer "+token) {
+			return httpmock.NewStringResponse(stdhttp.StatusUnauthorized, ""), nil
+		}
+		return Echo(req)
+	}
+}
+
+// authBody sends the request, returning the Authorization header and body it was sent with
+func authBody(t *testing.T, req *http.Request) string {
+	t.Helper()
+	resp, body := ReadBody(t, req)
+	return fmt.Sprintf("%v %s", resp.Headers.Values("Authorization"), body)
+}
+
+func TestAuth_Headers(t *testing.T) {
+	var valid atomic.Value
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterNoResponder(authorized(&valid))
+
+	client := http.NewClient(http.URLString("https://example.com/api"), http.BasicAuth("user", "pass"))
+
+	assert.Equal(t, "[Basic dXNlcjpwYXNz] ", authBody(t, client.Get()))
+
+	// clients and requests replace the header rather than adding to it
+	client2 := client.With(http.BearerToken("abc"))
+	assert.Equal(t, "[Bearer abc] ", authBody(t, client2.Get()))
+	assert.Equal(t, "[Bearer def] ", authBody(t, client2.Get(http.BearerToken("def"))))
+}
+
+func TestTokenSource_SingleFlight(t *testing.T) {
+	var valid atomic.Value
+	valid.Store("t1")
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterNoResponder(authorized(&valid))
+
+	var fetches int32
+	client := http.NewClient(http.URLString("https://example.com/api"), http.TokenSource(http.TokenFetcherFunc(func(ctx context.Context) (*http.Token, error) {
+		atomic.AddInt32(&fetches, 1)
+		time.Sleep(20 * time.Millisecond)
+		return &http.Token{AccessToken: "t1", Expiry: time.Now().Add(time.Hour)}, nil
+	})))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// only assert, as the test cannot be stopped from another goroutine
+			resp, err := client.Get().Send(context.Background())
+			if assert.NoError(t, err) {
+				assert.Equal(t, []string{"Bearer t1"}, resp.Headers.Values("Authorization"))
+			}
+		}()
+	}
+	wg.Wait()
+	assert.EqualValues(t, 1, atomic.LoadInt32(&fetches))
+}
+
+func TestTokenSource_CancelledWaiter(t *testing.T) {
+	var valid atomic.Value
+	valid.Store("t1")
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterNoResponder(authorized(&valid))
+
+	started, release := make(chan struct{}), make(chan struct{})
+	client := http.NewClient(http.URLString("https://example.com/api"), http.TokenSource(http.TokenFetcherFunc(func(ctx context.Context) (*http.Token, error) {
+		close(started)
+		<-release
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		return &http.Token{AccessToken: "t1"}, nil
+	})))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancelled := make(chan error)
+	go func() {
+		_, err := client.Get().Send(ctx)
+		cancelled <- err
+	}()
+	<-started
+
+	type result struct {
+		resp *http.Response
+		err  error
+	}
+	waiting := make(chan result)
+	go func() {
+		resp, err := client.Get().Send(context.Background())
+		waiting <- result{resp, err}
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	// the caller that started the fetch gives up, but the fetch carries on for the other caller
+	cancel()
+	assert.ErrorIs(t, <-cancelled, context.Canceled)
+	close(release)
+	waited := <-waiting
+	require.NoError(t, waited.err)
+	assert.Equal(t, []string{"Bearer t1"}, waited.resp.Headers.Values("Authorization"))
+}
+
+func TestTokenSource_Refresh(t *testing.T) {
+	var valid atomic.Value
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterNoResponder(authorized(&valid))
+
+	var fetches int32
+	client := http.NewClient(http.URLString("https://example.com/api"), http.TokenSource(http.TokenFetcherFunc(func(ctx context.Context) (*http.Token, error) {
+		n := atomic.AddInt32(&fetches, 1)
+		// the token is about to expire, so it is refreshed on the next request
+		return &http.Token{AccessToken: fmt.Sprintf("t%d", n), TokenType: "Bearer", Expiry: time.Now().Add(5 * time.Second)}, nil
+	})))
+
+	assert.Equal(t, "[Bearer t1] ", authBody(t, client.Get()))
+	assert.Equal(t, "[Bearer t2] ", authBody(t, client.Get()))
+}
+
+func TestTokenSource_Unauthorized(t *testing.T) {
+	var valid atomic.Value
+	valid.Store("t2")
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterNoResponder(authorized(&valid))
+
+	var fetches int32
+	source := http.TokenSource(http.TokenFetcherFunc(func(ctx context.Context) (*http.Token, error) {
+		return &http.Token{AccessToken: fmt.Sprintf("t%d", atomic.AddInt32(&fetches, 1))}, nil
+	}))
+	client := http.NewClient(http.URLString("https://example.com/api"))
+
+	// the first token is rejected, so the request is re-sent with a new one
+	assert.Equal(t, "[Bearer t2] hello", authBody(t, client.Post(http.Body(strings.NewReader("hello")), source)))
+
+	// the request is only re-sent once
+	valid.Store("t4")
+	resp, err := client.Get(source).Send(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.EqualValues(t, 3, atomic.LoadInt32(&fetches))
+}
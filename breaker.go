@@ -0,0 +1,208 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	stdhttp "net/http"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without sending the request while the circuit breaker of its host is open
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitState is the state of the circuit breaker of a host
+type CircuitState int
+
+const (
+	// CircuitClosed lets all requests through
+	CircuitClosed CircuitState = iota
+	// CircuitOpen fails all requests with ErrCircuitOpen
+	CircuitOpen
+	// CircuitHalfOpen lets a single probe request through to check if the host has recovered
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return fmt.Sprintf("CircuitState(%d)", int(s))
+}
+
+// CircuitBreakerConfig configures when the circuit breaker of a host opens and closes
+type CircuitBreakerConfig struct {
+	// FailureThreshold is the number of consecutive failures that open the circuit. Defaults to 5
+	FailureThreshold int
+	// OpenDuration is how long the circuit stays open before letting a probe request through. Defaults to 30s
+	OpenDuration time.Duration
+	// SuccessThreshold is the number of successful probes that close the circuit again. Defaults to 1
+	SuccessThreshold int
+	// Statuses are the response statuses counted as failures, along with transport errors.
+	// Defaults to all 5xx statuses
+	Statuses []Status
+	// OnStateChange is called whenever the circuit of a host changes state.
+	// It can be called concurrently for different hosts
+	OnStateChange func(host string, from, to CircuitState)
+}
+
+type CircuitBreakerOption struct {
+	breaker *circuitBreaker
+}
+
+// CircuitBreaker is an option to fail requests with ErrCircuitOpen without sending them
+// after a host has failed too many times in a row, until a probe request succeeds.
+// The state of the hosts is shared by all the clients the option is applied to
+func CircuitBreaker(config CircuitBreakerConfig) CircuitBreakerOption {
+	if config.FailureThreshold <= 0 {
+		config.FailureThreshold = 5
+	}
+	if config.OpenDuration <= 0 {
+		config.OpenDuration = 30 * time.Second
+	}
+	if config.SuccessThreshold <= 0 {
+		config.SuccessThreshold = 1
+	}
+	return CircuitBreakerOption{&circuitBreaker{
+		config:   config,
+		circuits: make(map[string]*circuit),
+	}}
+}
+
+func (o CircuitBreakerOption) ModifyClient(c *Client) {
+	TransportMiddlewares(func(next Doer) Doer {
+		return DoerFunc(func(req *stdhttp.Request) (*stdhttp.Response, error) {
+			return o.breaker.do(next, req)
+		})
+	}).ModifyClient(c)
+}
+
+type circuitBreaker struct {
+	config CircuitBreakerConfig
+
+	mu       sync.Mutex
+	circuits map[string]*circuit
+}
+
+type circuit struct {
+	state     CircuitState
+	failures  int
+	successes int
+	openedAt  time.Time
+	// probing is set while a probe request is in flight
+	probing bool
+}
+
+func (b *circuitBreaker) do(next Doer, req *stdhttp.Request) (*stdhttp.Response, error) {
+	host := req.URL.Host
+	if !b.allow(host) {
+		return nil, fmt.Errorf("cannot send request to %s: %w", host, ErrCircuitOpen)
+	}
+
+	resp, err := next.Do(req)
+	switch {
+	case err != nil && req.Context().Err() != nil:
+		// cancelled requests say nothing about the health of the host
+		b.release(host)
+	case err != nil || b.isFailure(resp.StatusCode):
+		b.record(host, false)
+	default:
+		b.record(host, true)
+	}
+	return resp, err
+}
+
+func (b *circuitBreaker) isFailure(status int) bool {
+	if b.config.Statuses == nil {
+		return Status(status).Type() == StatusTypeServerError
+	}
+	for _, s := range b.config.Statuses {
+		if Status(status) == s {
+			return true
+		}
+	}
+	return false
+}
+
+// allow reports whether a request can be sent to the host
+func (b *circuitBreaker) allow(host string) bool {
+	b.mu.Lock()
+	c, ok := b.circuits[host]
+	if !ok {
+		c = &circuit{}
+		b.circuits[host] = c
+	}
+
+	from := c.state
+	allowed := true
+	switch c.state {
+	case CircuitOpen:
+		if time.Since(c.openedAt) < b.config.OpenDuration {
+			allowed = false
+			break
+		}
+		c.state = CircuitHalfOpen
+		c.successes = 0
+		c.probing = true
+	case CircuitHalfOpen:
+		if c.probing {
+			allowed = false
+			break
+		}
+		c.probing = true
+	}
+	to := c.state
+	b.mu.Unlock()
+
+	b.changed(host, from, to)
+	return allowed
+}
+
+// release lets another probe through after a request that did not complete
+func (b *circuitBreaker) release(host string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if c := b.circuits[host]; c.state == CircuitHalfOpen {
+		c.probing = false
+	}
+}
+
+// record updates the circuit of the host with the outcome of a request
+func (b *circuitBreaker) record(host string, success bool) {
+	b.mu.Lock()
+	c := b.circuits[host]
+	from := c.state
+	switch c.state {
+	case CircuitClosed:
+		if success {
+			c.failures = 0
+		} else if c.failures++; c.failures >= b.config.FailureThreshold {
+			c.state = CircuitOpen
+			c.openedAt = time.Now()
+		}
+	case CircuitHalfOpen:
+		c.probing = false
+		if !success {
+			c.state = CircuitOpen
+			c.openedAt = time.Now()
+		} else if c.successes++; c.successes >= b.config.SuccessThreshold {
+			c.state = CircuitClosed
+			c.failures = 0
+		}
+	}
+	to := c.state
+	b.mu.Unlock()
+
+	b.changed(host, from, to)
+}
+
+func (b *circuitBreaker) changed(host string, from, to CircuitState) {
+	if from != to && b.config.OnStateChange != nil {
+		b.config.OnStateChange(host, from, to)
+	}
+}
@@ -0,0 +1,104 @@
+package http_test
+
+import (
+	"context"
+	"errors"
+	stdhttp "net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/conradludgate/go-http"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	var failing, hits int32 = 1, 0
+	server := httptest.NewServer(stdhttp.HandlerFunc(func(w stdhttp.ResponseWriter, r *stdhttp.Request) {
+		atomic.AddInt32(&hits, 1)
+		if atomic.LoadInt32(&failing) == 1 {
+			w.WriteHeader(stdhttp.StatusBadGateway)
+		}
+	}))
+	defer server.Close()
+
+	var mu sync.Mutex
+	var transitions []string
+	client := http.NewClient(http.URLString(server.URL), http.CircuitBreaker(http.CircuitBreakerConfig{
+		FailureThreshold: 2,
+		OpenDuration:     50 * time.Millisecond,
+		OnStateChange: func(host string, from, to http.CircuitState) {
+			assert.Equal(t, strings.TrimPrefix(server.URL, "http://"), host)
+			mu.Lock()
+			transitions = append(transitions, from.String()+" -> "+to.String())
+			mu.Unlock()
+		},
+	}))
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get().Send(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusBadGateway, resp.StatusCode)
+	}
+
+	// the circuit is open, the request is not sent
+	_, err := client.Get().Send(ctx)
+	assert.True(t, errors.Is(err, http.ErrCircuitOpen))
+	assert.EqualValues(t, 2, atomic.LoadInt32(&hits))
+
+	// a failed probe opens the circuit again
+	time.Sleep(60 * time.Millisecond)
+	_, err = client.Get().Send(ctx)
+	require.NoError(t, err)
+	_, err = client.Get().Send(ctx)
+	assert.True(t, errors.Is(err, http.ErrCircuitOpen))
+
+	// a successful probe closes it
+	atomic.StoreInt32(&failing, 0)
+	time.Sleep(60 * time.Millisecond)
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get().Send(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+	assert.EqualValues(t, 5, atomic.LoadInt32(&hits))
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{
+		"closed -> open",
+		"open -> half-open",
+		"half-open -> open",
+		"open -> half-open",
+		"half-open -> closed",
+	}, transitions)
+}
+
+func TestCircuitBreaker_NoRetry(t *testing.T) {
+	var hits int32
+	server := httptest.NewServer(stdhttp.HandlerFunc(func(w stdhttp.ResponseWriter, r *stdhttp.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.WriteHeader(stdhttp.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := http.NewClient(
+		http.URLString(server.URL),
+		fastRetry(5),
+		http.CircuitBreaker(http.CircuitBreakerConfig{
+			FailureThreshold: 2,
+			Statuses:         []http.Status{http.StatusServiceUnavailable},
+		}),
+	)
+
+	start := time.Now()
+	_, err := client.Get().Send(context.Background())
+	assert.True(t, errors.Is(err, http.ErrCircuitOpen))
+	assert.EqualValues(t, 2, atomic.LoadInt32(&hits))
+	assert.Less(t, int64(time.Since(start)), int64(time.Second))
+}
@@ -0,0 +1,504 @@
+package http
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"io"
+	stdhttp "net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CachedResponse is a response held in a CacheStore.
+// Cached responses are shared between requests, so they must not be modified once stored
+type CachedResponse struct {
+	StatusCode int
+	Header     stdhttp.Header
+	Body       []byte
+
+	// RequestTime is when the request for the response was sent
+	RequestTime time.Time
+	// ResponseTime is when the response was received
+	ResponseTime time.Time
+	// RequestHeader holds the request headers named by the Vary header of the response
+	RequestHeader stdhttp.Header
+}
+
+// CacheStore stores cached responses by key. Stores must be safe for concurrent use
+type CacheStore interface {
+	Get(key string) (*CachedResponse, bool)
+	Set(key string, resp *CachedResponse)
+	Delete(key string)
+}
+
+type CacheOption struct {
+	store CacheStore
+}
+
+// Cache is an option to cache responses to GET requests in store, as a private cache following RFC 9111.
+// Fresh responses are served from the store without sending the request. Stale responses are revalidated
+// with If-None-Match and If-Modified-Since headers, and a 304 Not Modified response is replaced with the cached response.
+//
+// Bodies of cacheable responses are read into memory before being stored.
+// Requests that already have conditional or Range headers bypass the cache
+func Cache(store CacheStore) CacheOption {
+	return CacheOption{store}
+}
+
+func (o CacheOption) ModifyClient(c *Client) {
+	TransportMiddlewares(func(next Doer) Doer {
+		return &cache{store: o.store, next: next}
+	}).ModifyClient(c)
+}
+
+type cache struct {
+	store CacheStore
+	next  Doer
+}
+
+func (c *cache) Do(req *stdhttp.Request) (*stdhttp.Response, error) {
+	if Method(req.Method) != Get {
+		resp, err := c.next.Do(req)
+		if err == nil && !isSafeMethod(req.Method) && resp.StatusCode < 400 {
+			c.invalidate(req, resp)
+		}
+		return resp, err
+	}
+	if bypassCache(req) {
+		return c.next.Do(req)
+	}
+
+	key := req.URL.String()
+	reqCC := parseCacheControl(req.Header)
+	if len(req.Header.Values("Cache-Control")) == 0 && req.Header.Get("Pragma") == "no-cache" {
+		reqCC["no-cache"] = ""
+	}
+
+	entry, ok := c.store.Get(key)
+	if ok && !entry.matches(req) {
+		ok = false
+	}
+	if ok && !reqCC.has("no-cache") && entry.fresh(time.Now(), reqCC) {
+		return entry.response(req), nil
+	}
+
+	out := req
+	if ok {
+		out = req.Clone(req.Context())
+		if etag := entry.Header.Get("ETag"); etag != "" {
+			out.Header.Set("If-None-Match", etag)
+		}
+		if lm := entry.Header.Get("Last-Modified"); lm != "" {
+			out.Header.Set("If-Modified-Since", lm)
+		}
+	}
+
+	requestTime := time.Now()
+	resp, err := c.next.Do(out)
+	if err != nil {
+		return nil, err
+	}
+	responseTime := time.Now()
+
+	if ok && resp.StatusCode == stdhttp.StatusNotModified {
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxDrainBytes))
+		_ = resp.Body.Close()
+
+		entry = entry.update(resp.Header, requestTime, responseTime)
+		if !reqCC.has("no-store") {
+			c.store.Set(key, entry)
+		}
+		return entry.response(req), nil
+	}
+
+	if !storable(resp, reqCC) {
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	c.store.Set(key, &CachedResponse{
+		StatusCode:    resp.StatusCode,
+		Header:        resp.Header.Clone(),
+		Body:          body,
+		RequestTime:   requestTime,
+		ResponseTime:  responseTime,
+		RequestHeader: varyHeaders(resp.Header, req.Header),
+	})
+	return resp, nil
+}
+
+// invalidate removes the responses for the URLs that an unsafe request may have changed
+func (c *cache) invalidate(req *stdhttp.Request, resp *stdhttp.Response) {
+	c.store.Delete(req.URL.String())
+	for _, header := range []string{"Location", "Content-Location"} {
+		loc := resp.Header.Get(header)
+		if loc == "" {
+			continue
+		}
+		u, err := req.URL.Parse(loc)
+		if err == nil && sameOrigin(u, req.URL) {
+			c.store.Delete(u.String())
+		}
+	}
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case "GET", "HEAD", "OPTIONS", "TRACE":
+		return true
+	}
+	return false
+}
+
+func sameOrigin(a, b *url.URL) bool {
+	return a.Scheme == b.Scheme && a.Host == b.Host
+}
+
+// bypassCache reports whether the request manages its own validation or ranges
+func bypassCache(req *stdhttp.Request) bool {
+	for _, header := range []string{"If-None-Match", "If-Modified-Since", "If-Match", "If-Unmodified-Since", "If-Range", "Range"} {
+		if req.Header.Get(header) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// heuristicStatuses are the statuses that can be cached without explicit freshness information
+var heuristicStatuses = map[int]bool{
+	200: true, 203: true, 204: true, 300: true, 301: true, 308: true,
+	404: true, 405: true, 410: true, 414: true, 501: true,
+}
+
+// storable reports whether the response to a GET request can be stored
+func storable(resp *stdhttp.Response, reqCC cacheControl) bool {
+	cc := parseCacheControl(resp.Header)
+	if reqCC.has("no-store") || cc.has("no-store") {
+		return false
+	}
+	if strings.TrimSpace(resp.Header.Get("Vary")) == "*" {
+		return false
+	}
+	_, explicit := cc.duration("max-age")
+	explicit = explicit || resp.Header.Get("Expires") != "" || cc.has("public")
+	if !explicit && !heuristicStatuses[resp.StatusCode] {
+		return false
+	}
+	hasValidator := resp.Header.Get("ETag") != "" || resp.Header.Get("Last-Modified") != ""
+	return hasValidator || lifetime(resp.StatusCode, resp.Header, time.Now()) > 0
+}
+
+// matches reports whether the request headers named by Vary are the same as for the cached response
+func (e *CachedResponse) matches(req *stdhttp.Request) bool {
+	for _, name := range varyNames(e.Header) {
+		if name == "*" {
+			return false
+		}
+		if strings.Join(req.Header.Values(name), ", ") != strings.Join(e.RequestHeader.Values(name), ", ") {
+			return false
+		}
+	}
+	return true
+}
+
+func varyNames(h stdhttp.Header) []string {
+	var names []string
+	for _, v := range h.Values("Vary") {
+		for _, name := range strings.Split(v, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, stdhttp.CanonicalHeaderKey(name))
+			}
+		}
+	}
+	return names
+}
+
+func varyHeaders(respHeader, reqHeader stdhttp.Header) stdhttp.Header {
+	names := varyNames(respHeader)
+	if len(names) == 0 {
+		return nil
+	}
+	h := stdhttp.Header{}
+	for _, name := range names {
+		if values := reqHeader.Values(name); len(values) > 0 {
+			h[name] = append([]string(nil), values...)
+		}
+	}
+	return h
+}
+
+// fresh reports whether the response can be served without revalidation
+func (e *CachedResponse) fresh(now time.Time, reqCC cacheControl) bool {
+	if parseCacheControl(e.Header).has("no-cache") {
+		return false
+	}
+	lifetime := lifetime(e.StatusCode, e.Header, e.ResponseTime)
+	age := e.age(now)
+	if d, ok := reqCC.duration("max-age"); ok && d < lifetime {
+		lifetime = d
+	}
+	if d, ok := reqCC.duration("min-fresh"); ok {
+		age += d
+	}
+	return lifetime > age
+}
+
+// lifetime returns the freshness lifetime of a response, using a heuristic
+// based on Last-Modified if the response has no explicit lifetime
+func lifetime(status int, header stdhttp.Header, responseTime time.Time) time.Duration {
+	cc := parseCacheControl(header)
+	if d, ok := cc.duration("max-age"); ok {
+		return d
+	}
+	date := headerDate(header, responseTime)
+	if expires := header.Get("Expires"); expires != "" {
+		t, err := stdhttp.ParseTime(expires)
+		if err != nil {
+			return 0 // invalid dates are in the past
+		}
+		return t.Sub(date)
+	}
+	if lm, err := stdhttp.ParseTime(header.Get("Last-Modified")); err == nil && heuristicStatuses[status] && lm.Before(date) {
+		return date.Sub(lm) / 10
+	}
+	return 0
+}
+
+// age returns the current age of the cached response
+func (e *CachedResponse) age(now time.Time) time.Duration {
+	apparent := e.ResponseTime.Sub(headerDate(e.Header, e.ResponseTime))
+	if apparent < 0 {
+		apparent = 0
+	}
+	var ageValue time.Duration
+	if seconds, err := strconv.ParseInt(e.Header.Get("Age"), 10, 64); err == nil && seconds > 0 {
+		ageValue = time.Duration(seconds) * time.Second
+	}
+	corrected := ageValue + e.ResponseTime.Sub(e.RequestTime)
+	if corrected > apparent {
+		apparent = corrected
+	}
+	return apparent + now.Sub(e.ResponseTime)
+}
+
+func headerDate(header stdhttp.Header, fallback time.Time) time.Time {
+	if date, err := stdhttp.ParseTime(header.Get("Date")); err == nil {
+		return date
+	}
+	return fallback
+}
+
+// update returns a copy of the cached response with the headers of a 304 Not Modified response
+func (e *CachedResponse) update(header stdhttp.Header, requestTime, responseTime time.Time) *CachedResponse {
+	e1 := *e
+	e1.Header = e.Header.Clone()
+	for name, values := range header {
+		if name == "Content-Length" {
+			continue
+		}
+		e1.Header[name] = values
+	}
+	e1.RequestTime = requestTime
+	e1.ResponseTime = responseTime
+	return &e1
+}
+
+// response creates a response to req from the cached response
+func (e *CachedResponse) response(req *stdhttp.Request) *stdhttp.Response {
+	header := e.Header.Clone()
+	header.Set("Age", strconv.FormatInt(int64(e.age(time.Now())/time.Second), 10))
+	return &stdhttp.Response{
+		Status:        fmt.Sprintf("%d %s", e.StatusCode, stdhttp.StatusText(e.StatusCode)),
+		StatusCode:    e.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}
+}
+
+// cacheControl holds the directives of Cache-Control headers
+type cacheControl map[string]string
+
+func parseCacheControl(h stdhttp.Header) cacheControl {
+	cc := cacheControl{}
+	for _, v := range h.Values("Cache-Control") {
+		for _, directive := range strings.Split(v, ",") {
+			directive = strings.TrimSpace(directive)
+			if directive == "" {
+				continue
+			}
+			name, value := directive, ""
+			if i := strings.IndexByte(directive, '='); i >= 0 {
+				name, value = directive[:i], strings.Trim(strings.TrimSpace(directive[i+1:]), `"`)
+			}
+			cc[strings.ToLower(strings.TrimSpace(name))] = value
+		}
+	}
+	return cc
+}
+
+func (cc cacheControl) has(directive string) bool {
+	_, ok := cc[directive]
+	return ok
+}
+
+func (cc cacheControl) duration(directive string) (time.Duration, bool) {
+	value, ok := cc[directive]
+	if !ok {
+		return 0, false
+	}
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	return time.Duration(seconds) * time.Second, true
+}
+
+// MemoryCache is a CacheStore holding responses in memory,
+// evicting the least recently used ones once it is full
+type MemoryCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	order      *list.List
+	entries    map[string]*list.Element
+}
+
+type memoryCacheEntry struct {
+	key  string
+	resp *CachedResponse
+}
+
+// NewMemoryCache creates a MemoryCache holding up to maxEntries responses.
+// If maxEntries is zero, the cache has no limit
+func NewMemoryCache(maxEntries int) *MemoryCache {
+	return &MemoryCache{
+		maxEntries: maxEntries,
+		order:      list.New(),
+		entries:    make(map[string]*list.Element),
+	}
+}
+
+func (m *MemoryCache) Get(key string) (*CachedResponse, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	elem, ok := m.entries[key]
+	if !ok {
+		return nil, false
+	}
+	m.order.MoveToFront(elem)
+	return elem.Value.(*memoryCacheEntry).resp, true
+}
+
+func (m *MemoryCache) Set(key string, resp *CachedResponse) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if elem, ok := m.entries[key]; ok {
+		elem.Value.(*memoryCacheEntry).resp = resp
+		m.order.MoveToFront(elem)
+		return
+	}
+	m.entries[key] = m.order.PushFront(&memoryCacheEntry{key, resp})
+	if m.maxEntries > 0 && m.order.Len() > m.maxEntries {
+		oldest := m.order.Back()
+		m.order.Remove(oldest)
+		delete(m.entries, oldest.Value.(*memoryCacheEntry).key)
+	}
+}
+
+func (m *MemoryCache) Delete(key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if elem, ok := m.entries[key]; ok {
+		m.order.Remove(elem)
+		delete(m.entries, key)
+	}
+}
+
+// Len returns the number of responses in the cache
+func (m *MemoryCache) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.order.Len()
+}
+
+// DiskCache is a CacheStore holding responses as files in a directory, so that they outlive the process.
+// Errors reading or writing the files are treated as cache misses
+type DiskCache struct {
+	dir string
+}
+
+// NewDiskCache creates a DiskCache storing files in dir, which is created if needed
+func NewDiskCache(dir string) *DiskCache {
+	return &DiskCache{dir}
+}
+
+type diskCacheEntry struct {
+	Key  string
+	Resp *CachedResponse
+}
+
+func (d *DiskCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(d.dir, hex.EncodeToString(sum[:]))
+}
+
+func (d *DiskCache) Get(key string) (*CachedResponse, bool) {
+	f, err := os.Open(d.path(key))
+	if err != nil {
+		return nil, false
+	}
+	defer f.Close()
+
+	var entry diskCacheEntry
+	if err := gob.NewDecoder(f).Decode(&entry); err != nil || entry.Key != key || entry.Resp == nil {
+		return nil, false
+	}
+	return entry.Resp, true
+}
+
+func (d *DiskCache) Set(key string, resp *CachedResponse) {
+	if err := os.MkdirAll(d.dir, 0o755); err != nil {
+		return
+	}
+	// write to a temporary file first so that readers never see a partial entry
+	f, err := os.CreateTemp(d.dir, ".*.tmp")
+	if err != nil {
+		return
+	}
+	err = gob.NewEncoder(f).Encode(diskCacheEntry{key, resp})
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), d.path(key))
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+	}
+}
+
+func (d *DiskCache) Delete(key string) {
+	_ = os.Remove(d.path(key))
+}
@@ -0,0 +1,157 @@
+package http_test
+
+import (
+	stdhttp "net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"github.com/conradludgate/go-http"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// cacheServer responds with the Cache-Control header given by the cc query,
+// and an ETag that is revalidated with 304 Not Modified
+func cacheServer(hits *int32) *httptest.Server {
+	return httptest.NewServer(stdhttp.HandlerFunc(func(w stdhttp.ResponseWriter, r *stdhttp.Request) {
+		atomic.AddInt32(hits, 1)
+		if r.Method != stdhttp.MethodGet {
+			return
+		}
+		w.Header().Set("Cache-Control", r.URL.Query().Get("cc"))
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Vary", "Accept")
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(stdhttp.StatusNotModified)
+			return
+		}
+		_, _ = w.Write([]byte("config for " + r.Header.Get("Accept")))
+	}))
+}
+
+func TestCache_Fresh(t *testing.T) {
+	var hits int32
+	server := cacheServer(&hits)
+	defer server.Close()
+
+	client := http.NewClient(http.URLString(server.URL), http.Cache(http.NewMemoryCache(10)))
+
+	for i := 0; i < 3; i++ {
+		resp, body := ReadBody(t, client.Get(http.Params(url.Values{"cc": {"max-age=60"}})), http.ExpectSuccess())
+		assert.Equal(t, "config for ", body)
+		if i > 0 {
+			// the Date header has a resolution of a second
+			age, err := strconv.Atoi(resp.Headers.Get("Age"))
+			require.NoError(t, err)
+			assert.LessOrEqual(t, age, 1)
+		}
+	}
+	assert.EqualValues(t, 1, atomic.LoadInt32(&hits))
+
+	// no-cache requests always revalidate
+	_, body := ReadBody(t, client.Get(http.Params(url.Values{"cc": {"max-age=60"}}), http.SetHeader("Cache-Control", "no-cache")), http.ExpectSuccess())
+	assert.Equal(t, "config for ", body)
+	assert.EqualValues(t, 2, atomic.LoadInt32(&hits))
+}
+
+func TestCache_Revalidate(t *testing.T) {
+	var hits int32
+	server := cacheServer(&hits)
+	defer server.Close()
+
+	client := http.NewClient(http.URLString(server.URL), http.Cache(http.NewMemoryCache(10)))
+
+	for i := 0; i < 3; i++ {
+		resp, body := ReadBody(t, client.Get(http.Params(url.Values{"cc": {"no-cache"}})), http.ExpectSuccess())
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "config for ", body)
+		assert.Equal(t, `"v1"`, resp.Headers.Get("ETag"))
+	}
+	assert.EqualValues(t, 3, atomic.LoadInt32(&hits))
+}
+
+func TestCache_NoStore(t *testing.T) {
+	var hits int32
+	server := cacheServer(&hits)
+	defer server.Close()
+
+	store := http.NewMemoryCache(10)
+	client := http.NewClient(http.URLString(server.URL), http.Cache(store))
+
+	for i := 0; i < 2; i++ {
+		_, body := ReadBody(t, client.Get(http.Params(url.Values{"cc": {"no-store"}})), http.ExpectSuccess())
+		assert.Equal(t, "config for ", body)
+	}
+	assert.EqualValues(t, 2, atomic.LoadInt32(&hits))
+	assert.Equal(t, 0, store.Len())
+}
+
+func TestCache_Vary(t *testing.T) {
+	var hits int32
+	server := cacheServer(&hits)
+	defer server.Close()
+
+	client := http.NewClient(http.URLString(server.URL), http.Cache(http.NewMemoryCache(10)))
+
+	_, body := ReadBody(t, client.Get(http.Params(url.Values{"cc": {"max-age=60"}}), http.SetHeader("Accept", "text/plain")), http.ExpectSuccess())
+	assert.Equal(t, "config for text/plain", body)
+	_, body = ReadBody(t, client.Get(http.Params(url.Values{"cc": {"max-age=60"}}), http.SetHeader("Accept", "application/json")), http.ExpectSuccess())
+	assert.Equal(t, "config for application/json", body)
+	assert.EqualValues(t, 2, atomic.LoadInt32(&hits))
+}
+
+func TestCache_Invalidate(t *testing.T) {
+	var hits int32
+	server := cacheServer(&hits)
+	defer server.Close()
+
+	client := http.NewClient(http.URLString(server.URL), http.Cache(http.NewMemoryCache(10)))
+
+	ReadBody(t, client.Get(http.Params(url.Values{"cc": {"max-age=60"}})), http.ExpectSuccess())
+	ReadBody(t, client.Post(http.Params(url.Values{"cc": {"max-age=60"}})), http.ExpectSuccess())
+	ReadBody(t, client.Get(http.Params(url.Values{"cc": {"max-age=60"}})), http.ExpectSuccess())
+	assert.EqualValues(t, 3, atomic.LoadInt32(&hits))
+}
+
+func TestMemoryCache_Evicts(t *testing.T) {
+	store := http.NewMemoryCache(2)
+	store.Set("a", &http.CachedResponse{})
+	store.Set("b", &http.CachedResponse{})
+	_, ok := store.Get("a")
+	require.True(t, ok)
+	store.Set("c", &http.CachedResponse{})
+
+	_, ok = store.Get("b")
+	assert.False(t, ok, "least recently used entry should be evicted")
+	_, ok = store.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 2, store.Len())
+}
+
+func TestDiskCache(t *testing.T) {
+	var hits int32
+	server := cacheServer(&hits)
+	defer server.Close()
+
+	dir := t.TempDir()
+	for i := 0; i < 2; i++ {
+		// a new client and store each time, sharing the directory
+		client := http.NewClient(http.URLString(server.URL), http.Cache(http.NewDiskCache(dir)))
+		_, body := ReadBody(t, client.Get(http.Params(url.Values{"cc": {"max-age=60"}})), http.ExpectSuccess())
+		assert.Equal(t, "config for ", body)
+	}
+	assert.EqualValues(t, 1, atomic.LoadInt32(&hits))
+
+	store := http.NewDiskCache(dir)
+	key := server.URL + "?cc=max-age%3D60"
+	cached, ok := store.Get(key)
+	require.True(t, ok)
+	assert.Equal(t, "config for ", string(cached.Body))
+
+	store.Delete(key)
+	_, ok = store.Get(key)
+	assert.False(t, ok)
+}
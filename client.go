@@ -2,10 +2,11 @@ package http
 
 import (
 	stdhttp "net/http"
-
-	copy "github.com/mitchellh/copystructure"
 )
 
+// Client sends HTTP requests. A client is safe for concurrent use by multiple goroutines,
+// as long as it is not modified with Apply while requests are being made.
+// Each request gets its own copy of the URL and headers provided by the client options
 type Client struct {
 	baseClient *stdhttp.Client
 
@@ -14,6 +15,10 @@ type Client struct {
 
 	PreResponseMiddlewares  []ResponseOption
 	PostResponseMiddlewares []ResponseOption
+
+	TransportMiddlewares []TransportMiddleware
+
+	codecs []Codec
 }
 
 // NewClient creates a new HTTP Client with the given options
@@ -23,14 +28,19 @@ func NewClient(options ...ClientOption) *Client {
 	return c
 }
 
+// copy makes a shallow copy of the client.
+// Options are never modified once created, so they can be shared between clients,
+// but the slices holding them must not be
 func (c *Client) copy() *Client {
 	c1 := new(Client)
 
-	c1.baseClient = copy.Must(copy.Copy(c.baseClient)).(*stdhttp.Client)
-	c1.PreRequestMiddlewares = copy.Must(copy.Copy(c.PreRequestMiddlewares)).([]RequestOption)
-	c1.PostRequestMiddlewares = copy.Must(copy.Copy(c.PostRequestMiddlewares)).([]RequestOption)
-	c1.PreResponseMiddlewares = copy.Must(copy.Copy(c.PreResponseMiddlewares)).([]ResponseOption)
-	c1.PostResponseMiddlewares = copy.Must(copy.Copy(c.PostResponseMiddlewares)).([]ResponseOption)
+	c1.baseClient = c.baseClient
+	c1.PreRequestMiddlewares = append([]RequestOption(nil), c.PreRequestMiddlewares...)
+	c1.PostRequestMiddlewares = append([]RequestOption(nil), c.PostRequestMiddlewares...)
+	c1.PreResponseMiddlewares = append([]ResponseOption(nil), c.PreResponseMiddlewares...)
+	c1.PostResponseMiddlewares = append([]ResponseOption(nil), c.PostResponseMiddlewares...)
+	c1.TransportMiddlewares = append([]TransportMiddleware(nil), c.TransportMiddlewares...)
+	c1.codecs = append([]Codec(nil), c.codecs...)
 
 	return c1
 }
@@ -42,7 +52,8 @@ func (c *Client) With(options ...ClientOption) *Client {
 	return c1
 }
 
-// With clones the client, applying the new options ontop
+// Apply modifies the client in place with the new options.
+// It must not be called concurrently with requests being made from the client
 func (c *Client) Apply(options ...ClientOption) {
 	for _, opt := range options {
 		opt.ModifyClient(c)
@@ -76,7 +87,7 @@ func (c *Client) NewRequest(method Method, options ...RequestOption) *Request {
 		Method: method,
 	}
 
-	req.err = req.applyOptions(c.PreRequestMiddlewares...)
+	req.err = req.applyOptions(ordered(c.PreRequestMiddlewares)...)
 	if req.err != nil {
 		return &req
 	}
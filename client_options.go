@@ -2,6 +2,7 @@ package http
 
 import (
 	stdhttp "net/http"
+	"sort"
 )
 
 // ClientOption is the option type for clients
@@ -21,6 +22,33 @@ func (h HeaderOption) ModifyClient(c *Client) {
 	PreRequestMiddlewares(h).ModifyClient(c)
 }
 
+func (h SetHeaderOption) ModifyClient(c *Client) {
+	PreRequestMiddlewares(h).ModifyClient(c)
+}
+
+func (h DelHeaderOption) ModifyClient(c *Client) {
+	PreRequestMiddlewares(h).ModifyClient(c)
+}
+
+// HeaderFuncOption is applied as a PostRequestMiddleware so that
+// the function sees the request after all of its options have been applied.
+// Headers already set on the request are left unchanged, so that request options override the client
+func (h HeaderFuncOption) ModifyClient(c *Client) {
+	PostRequestMiddlewares(defaultHeaderFunc{h}).ModifyClient(c)
+}
+
+// defaultHeaderFunc applies a HeaderFunc unless the header is already set
+type defaultHeaderFunc struct {
+	HeaderFuncOption
+}
+
+func (h defaultHeaderFunc) ModifyRequest(r *Request) error {
+	if r.Headers.Get(h.key) != "" {
+		return nil
+	}
+	return h.HeaderFuncOption.ModifyRequest(r)
+}
+
 type PreRequestOptions struct {
 	options []RequestOption
 }
@@ -33,6 +61,42 @@ func (r PreRequestOptions) ModifyClient(c *Client) {
 	c.PreRequestMiddlewares = append(c.PreRequestMiddlewares, r.options...)
 }
 
+// OrderedOption can be implemented by request options that need to run at a particular point among the
+// request middlewares of a client, such as options signing the request once it is complete.
+// Middlewares run in ascending order, and in the order they were added when their orders are equal.
+// Options that don't implement it have an order of 0
+type OrderedOption interface {
+	Order() int
+}
+
+// orderOf returns the order of a request option
+func orderOf(option RequestOption) int {
+	if o, ok := option.(OrderedOption); ok {
+		return o.Order()
+	}
+	return 0
+}
+
+// ordered returns the middlewares sorted by their order
+func ordered(middlewares []RequestOption) []RequestOption {
+	sorted := true
+	for i := 1; i < len(middlewares); i++ {
+		if orderOf(middlewares[i-1]) > orderOf(middlewares[i]) {
+			sorted = false
+			break
+		}
+	}
+	if sorted {
+		return middlewares
+	}
+
+	middlewares = append([]RequestOption(nil), middlewares...)
+	sort.SliceStable(middlewares, func(i, j int) bool {
+		return orderOf(middlewares[i]) < orderOf(middlewares[j])
+	})
+	return middlewares
+}
+
 type PostRequestOptions struct {
 	options []RequestOption
 }
@@ -69,6 +133,21 @@ func (r PostResponseOptions) ModifyClient(c *Client) {
 	c.PostResponseMiddlewares = append(c.PostResponseMiddlewares, r.options...)
 }
 
+type TransportOptions struct {
+	middlewares []TransportMiddleware
+}
+
+// TransportMiddlewares is an option to add transport middlewares to a client.
+// The first middleware added is the outermost one, so it sees requests first and responses last.
+// Each retry attempt of a request goes through all of the transport middlewares
+func TransportMiddlewares(middlewares ...TransportMiddleware) TransportOptions {
+	return TransportOptions{middlewares}
+}
+
+func (t TransportOptions) ModifyClient(c *Client) {
+	c.TransportMiddlewares = append(c.TransportMiddlewares, t.middlewares...)
+}
+
 type BaseClientOption struct {
 	client *stdhttp.Client
 }
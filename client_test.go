@@ -0,0 +1,98 @@
+package http_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/conradludgate/go-http"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_RequestIsolation(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterNoResponder(Echo)
+
+	client := http.NewClient(
+		http.URL(URL(t, "https://example.com/api")),
+		http.AddHeader("X-Test", "client"),
+	)
+
+	for i := 0; i < 3; i++ {
+		resp, _ := ReadBody(t, client.Get(
+			http.Path("foo"),
+			http.Param("i", "1"),
+			http.AddHeader("X-Test", "request"),
+		))
+		assert.Equal(t, "/api/foo?i=1", resp.Headers.Get("X-Request-Uri"))
+		assert.Equal(t, []string{"client", "request"}, resp.Headers.Values("X-Test"))
+	}
+}
+
+func TestClient_WithIsolation(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterNoResponder(Echo)
+
+	client := http.NewClient(
+		http.URLString("https://example.com/api"),
+		http.AddHeader("X-Test", "client"),
+	)
+	client2 := client.With(http.AddHeader("X-Test", "client2"))
+
+	resp, _ := ReadBody(t, client2.Get(http.Path("two")))
+	assert.Equal(t, "/api/two", resp.Headers.Get("X-Request-Uri"))
+	assert.Equal(t, []string{"client", "client2"}, resp.Headers.Values("X-Test"))
+
+	resp, _ = ReadBody(t, client.Get(http.Path("one")))
+	assert.Equal(t, "/api/one", resp.Headers.Get("X-Request-Uri"))
+	assert.Equal(t, []string{"client"}, resp.Headers.Values("X-Test"))
+}
+
+// TestClient_Concurrent is most useful when run with the race detector
+func TestClient_Concurrent(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterNoResponder(Echo)
+
+	client := http.NewClient(
+		http.URL(URL(t, "https://example.com/api")),
+		http.AddHeader("X-Test", "client"),
+		http.PreRequestMiddlewares(http.Param("shared", "true")),
+	)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			id := fmt.Sprint(i)
+			c := client
+			if i%2 == 0 {
+				c = client.With(http.AddHeader("X-Test", "with"))
+			}
+
+			resp, err := c.Get(
+				http.Path("items", id),
+				http.Param("id", id),
+				http.AddHeader("X-Test", id),
+			).Send(ctx)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			headers := []string{"client", id}
+			if i%2 == 0 {
+				headers = []string{"client", "with", id}
+			}
+			assert.Equal(t, "/api/items/"+id+"?id="+id+"&shared=true", resp.Headers.Get("X-Request-Uri"))
+			assert.Equal(t, headers, resp.Headers.Values("X-Test"))
+		}(i)
+	}
+	wg.Wait()
+}
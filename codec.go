@@ -0,0 +1,211 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Codec encodes and decodes request and response bodies of some content types
+type Codec interface {
+	// ContentTypes lists the media types handled by the codec.
+	// The first one is used as the Content-Type of encoded requests.
+	// A codec also handles media types with a structured syntax suffix of its subtype,
+	// eg a codec for application/json handles application/vnd.foo+json
+	ContentTypes() []string
+	Encode(w io.Writer, v interface{}) error
+	Decode(r io.Reader, v interface{}) error
+}
+
+// JSONCodec is the Codec for application/json bodies
+type JSONCodec struct{}
+
+func (JSONCodec) ContentTypes() []string {
+	return []string{"application/json"}
+}
+
+func (JSONCodec) Encode(w io.Writer, v interface{}) error {
+	return json.NewEncoder(w).Encode(v)
+}
+
+func (JSONCodec) Decode(r io.Reader, v interface{}) error {
+	return json.NewDecoder(r).Decode(v)
+}
+
+// defaultCodecs are available to every client. The first one is used when no content type is known
+var defaultCodecs = []Codec{JSONCodec{}, XMLCodec{}}
+
+// mediaType returns the lowercase media type of a Content-Type header, without any parameters
+func mediaType(ct string) string {
+	mt, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		mt = strings.ToLower(strings.TrimSpace(strings.SplitN(ct, ";", 2)[0]))
+	}
+	return mt
+}
+
+// matchMediaType reports whether the media type mt is handled by a codec for codecType
+func matchMediaType(codecType, mt string) bool {
+	if mt == codecType {
+		return true
+	}
+	i := strings.LastIndexByte(mt, '+')
+	if i < 0 {
+		return false
+	}
+	j := strings.IndexByte(codecType, '/')
+	return j >= 0 && mt[i+1:] == codecType[j+1:]
+}
+
+// codecList lists the codecs of the client, most recently registered first, followed by the defaults
+func (c *Client) codecList() []Codec {
+	if c == nil {
+		return defaultCodecs
+	}
+	codecs := make([]Codec, 0, len(c.codecs)+len(defaultCodecs))
+	for i := len(c.codecs) - 1; i >= 0; i-- {
+		codecs = append(codecs, c.codecs[i])
+	}
+	return append(codecs, defaultCodecs...)
+}
+
+// codecFor finds the codec for a content type, preferring exact matches over suffix matches
+func (c *Client) codecFor(ct string) Codec {
+	mt := mediaType(ct)
+	codecs := c.codecList()
+	for _, codec := range codecs {
+		for _, t := range codec.ContentTypes() {
+			if t == mt {
+				return codec
+			}
+		}
+	}
+	for _, codec := range codecs {
+		for _, t := range codec.ContentTypes() {
+			if matchMediaType(t, mt) {
+				return codec
+			}
+		}
+	}
+	return nil
+}
+
+// negotiate picks the codec and content type for a request body from an Accept header
+func (c *Client) negotiate(accept string) (Codec, string) {
+	type acceptRange struct {
+		mediaType string
+		q         float64
+	}
+	var ranges []acceptRange
+	for _, part := range strings.Split(accept, ",") {
+		mt, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		if q > 0 {
+			ranges = append(ranges, acceptRange{mt, q})
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].q > ranges[j].q })
+
+	for _, r := range ranges {
+		if strings.HasSuffix(r.mediaType, "/*") {
+			prefix := strings.TrimSuffix(r.mediaType, "*")
+			for _, codec := range c.codecList() {
+				for _, t := range codec.ContentTypes() {
+					if r.mediaType == "*/*" || strings.HasPrefix(t, prefix) {
+						return codec, t
+					}
+				}
+			}
+			continue
+		}
+		if codec := c.codecFor(r.mediaType); codec != nil {
+			return codec, r.mediaType
+		}
+	}
+	return nil, ""
+}
+
+type EncodeOption struct {
+	v interface{}
+}
+
+// Encode is an option to add a body to a request using one of the client's codecs.
+// The codec is picked from the Content-Type header of the request if it is set,
+// otherwise from the Accept header, falling back to JSON
+func Encode(v interface{}) EncodeOption {
+	return EncodeOption{v}
+}
+
+func (e EncodeOption) ModifyRequest(r *Request) error {
+	var codec Codec
+	ct := r.Headers.Get("Content-Type")
+	if ct != "" {
+		if codec = r.Client.codecFor(ct); codec == nil {
+			return fmt.Errorf("no codec for Content-Type %s", ct)
+		}
+	} else if accept := r.Headers.Get("Accept"); accept != "" {
+		codec, ct = r.Client.negotiate(accept)
+	}
+	if codec == nil {
+		codec = defaultCodecs[0]
+		ct = codec.ContentTypes()[0]
+	}
+
+	b := bytes.NewBuffer(nil)
+	if err := codec.Encode(b, e.v); err != nil {
+		return fmt.Errorf("cannot encode request body: %w", err)
+	}
+	return r.applyOptions(Body(b), SetHeader("Content-Type", ct))
+}
+
+type DecodeOption struct {
+	v interface{}
+}
+
+// Decode is an option to decode a response body using the client codec for its Content-Type.
+// Responses without a Content-Type are decoded as JSON
+func Decode(v interface{}) DecodeOption {
+	return DecodeOption{v}
+}
+
+func (d DecodeOption) ProcessResponse(resp *Response) error {
+	var client *Client
+	if resp.Request != nil {
+		client = resp.Request.Client
+	}
+
+	codec := defaultCodecs[0]
+	if ct := resp.Headers.Get("Content-Type"); ct != "" {
+		if codec = client.codecFor(ct); codec == nil {
+			return fmt.Errorf("no codec for Content-Type %s", ct)
+		}
+	}
+	return codec.Decode(resp, d.v)
+}
+
+type CodecsOption struct {
+	codecs []Codec
+}
+
+// Codecs is an option to register codecs on a client, used by the Encode and Decode options.
+// Codecs registered later take precedence over earlier ones and over the default JSON and XML codecs
+func Codecs(codecs ...Codec) CodecsOption {
+	return CodecsOption{codecs}
+}
+
+func (o CodecsOption) ModifyClient(c *Client) {
+	c.codecs = append(c.codecs, o.codecs...)
+}
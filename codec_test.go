@@ -0,0 +1,97 @@
+package http_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/conradludgate/go-http"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// textCodec encodes *string values as text/plain
+type textCodec struct{}
+
+func (textCodec) ContentTypes() []string {
+	return []string{"text/plain"}
+}
+
+func (textCodec) Encode(w io.Writer, v interface{}) error {
+	s, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("cannot encode %T as text", v)
+	}
+	_, err := io.WriteString(w, s)
+	return err
+}
+
+func (textCodec) Decode(r io.Reader, v interface{}) error {
+	s, ok := v.(*string)
+	if !ok {
+		return fmt.Errorf("cannot decode text into %T", v)
+	}
+	b, err := io.ReadAll(r)
+	*s = string(b)
+	return err
+}
+
+func TestCodecs(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterNoResponder(Echo)
+
+	client := http.NewClient(http.URLString("https://example.com/api"), http.Codecs(textCodec{}))
+	ctx := context.Background()
+
+	// defaults to JSON
+	var foobar FooBar
+	resp, err := client.Post(http.Encode(FooBar{Foo: "foo", Bar: 1})).Send(ctx, http.Decode(&foobar))
+	require.NoError(t, err)
+	assert.Equal(t, "application/json", resp.Headers.Get("Content-Type"))
+	assert.Equal(t, FooBar{Foo: "foo", Bar: 1}, foobar)
+
+	// picks the codec from the Content-Type
+	var text string
+	resp, err = client.Post(
+		http.SetHeader("Content-Type", "text/plain; charset=utf-8"),
+		http.Encode("hello"),
+	).Send(ctx, http.Decode(&text))
+	require.NoError(t, err)
+	assert.Equal(t, "text/plain; charset=utf-8", resp.Headers.Get("Content-Type"))
+	assert.Equal(t, "hello", text)
+
+	// negotiates vendor types from the Accept header
+	foobar = FooBar{}
+	resp, err = client.Post(
+		http.SetHeader("Accept", "application/xml;q=0.5, application/vnd.foo+json"),
+		http.Encode(FooBar{Foo: "vnd", Bar: 2}),
+	).Send(ctx, http.Decode(&foobar))
+	require.NoError(t, err)
+	assert.Equal(t, "application/vnd.foo+json", resp.Headers.Get("Content-Type"))
+	assert.Equal(t, FooBar{Foo: "vnd", Bar: 2}, foobar)
+}
+
+func TestCodecs_Errors(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterNoResponder(Echo)
+
+	client := http.NewClient(http.URLString("https://example.com/api"))
+	ctx := context.Background()
+
+	err := client.Post(
+		http.SetHeader("Content-Type", "text/plain"),
+		http.Encode("hello"),
+	).Error()
+	assert.EqualError(t, err, "no codec for Content-Type text/plain")
+
+	var text string
+	_, err = client.Post(
+		http.SetHeader("Content-Type", "text/plain"),
+		http.Body(nil),
+	).Send(ctx, http.Decode(&text))
+	assert.EqualError(t, err, "no codec for Content-Type text/plain")
+}
@@ -0,0 +1,100 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	stdhttp "net/http"
+	"strings"
+	"time"
+)
+
+// ErrPreconditionFailed is matched by the error returned when a conditional request
+// fails with 412 Precondition Failed, such as a Put with IfMatch on a resource that has since changed.
+// It can be checked for using errors.Is
+var ErrPreconditionFailed = errors.New("precondition failed")
+
+type PreconditionOption struct {
+	header string
+	value  string
+	err    error
+}
+
+// IfMatch is an option to only apply the request if the resource still has the given ETag.
+// Send fails with an error matching ErrPreconditionFailed if it does not
+func IfMatch(etag string) PreconditionOption {
+	return etagPrecondition("If-Match", etag)
+}
+
+// IfNoneMatch is an option to only apply the request if the resource does not have the given ETag.
+// Use "*" to only create a resource if it does not exist yet
+func IfNoneMatch(etag string) PreconditionOption {
+	return etagPrecondition("If-None-Match", etag)
+}
+
+// IfModifiedSince is an option to only get the resource if it was modified after t
+func IfModifiedSince(t time.Time) PreconditionOption {
+	return timePrecondition("If-Modified-Since", t)
+}
+
+// IfUnmodifiedSince is an option to only apply the request if the resource was not modified after t.
+// Send fails with an error matching ErrPreconditionFailed if it was
+func IfUnmodifiedSince(t time.Time) PreconditionOption {
+	return timePrecondition("If-Unmodified-Since", t)
+}
+
+func etagPrecondition(header, etag string) PreconditionOption {
+	if etag == "" {
+		return PreconditionOption{err: fmt.Errorf("cannot use empty etag in %s header", header)}
+	}
+	// allow etags to be given without their quotes
+	if etag != "*" && !strings.HasPrefix(etag, `"`) && !strings.HasPrefix(etag, `W/"`) {
+		etag = `"` + etag + `"`
+	}
+	return PreconditionOption{header: header, value: etag}
+}
+
+func timePrecondition(header string, t time.Time) PreconditionOption {
+	if t.IsZero() {
+		return PreconditionOption{err: fmt.Errorf("cannot use zero time in %s header", header)}
+	}
+	return PreconditionOption{header: header, value: t.UTC().Format(stdhttp.TimeFormat)}
+}
+
+func (p PreconditionOption) ModifyRequest(r *Request) error {
+	if p.err != nil {
+		return p.err
+	}
+	if r.Headers == nil {
+		r.Headers = make(stdhttp.Header)
+	}
+	r.Headers.Set(p.header, p.value)
+	r.conditional = true
+	return nil
+}
+
+// ETag returns the ETag header of the response, including its quotes
+func (r *Response) ETag() string {
+	return r.Headers.Get("ETag")
+}
+
+// LastModified returns the time from the Last-Modified header of the response,
+// or the zero time if it is missing or invalid
+func (r *Response) LastModified() time.Time {
+	t, err := stdhttp.ParseTime(r.Headers.Get("Last-Modified"))
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// checkPrecondition fails responses to conditional requests with 412 Precondition Failed
+func (r *Response) checkPrecondition() error {
+	if r.StatusCode != StatusPreconditionFailed || r.Request == nil || !r.Request.conditional {
+		return nil
+	}
+	statusErr, err := newStatusError(r)
+	if err != nil {
+		return fmt.Errorf("cannot read response body: %w", err)
+	}
+	return statusErr
+}
@@ -0,0 +1,103 @@
+package http_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	stdhttp "net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/conradludgate/go-http"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// versionedServer holds a single resource, versioned by its ETag
+func versionedServer() *httptest.Server {
+	var mu sync.Mutex
+	value, version := "v0", 0
+	modified := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	return httptest.NewServer(stdhttp.HandlerFunc(func(w stdhttp.ResponseWriter, r *stdhttp.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		etag := fmt.Sprintf(`"%d"`, version)
+		switch r.Method {
+		case stdhttp.MethodGet:
+			w.Header().Set("ETag", etag)
+			w.Header().Set("Last-Modified", modified.Format(stdhttp.TimeFormat))
+			if r.Header.Get("If-None-Match") == etag {
+				w.WriteHeader(stdhttp.StatusNotModified)
+				return
+			}
+			_, _ = io.WriteString(w, value)
+		case stdhttp.MethodPut:
+			if r.Header.Get("If-Match") != etag {
+				w.WriteHeader(stdhttp.StatusPreconditionFailed)
+				return
+			}
+			b, _ := io.ReadAll(r.Body)
+			value, version = string(b), version+1
+			w.Header().Set("ETag", fmt.Sprintf(`"%d"`, version))
+			w.WriteHeader(stdhttp.StatusNoContent)
+		}
+	}))
+}
+
+func TestConditional_OptimisticConcurrency(t *testing.T) {
+	server := versionedServer()
+	defer server.Close()
+
+	client := http.NewClient(http.URLString(server.URL))
+	ctx := context.Background()
+
+	resp, err := client.Get().Send(ctx)
+	require.NoError(t, err)
+	etag := resp.ETag()
+	assert.Equal(t, `"0"`, etag)
+	assert.Equal(t, time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC), resp.LastModified())
+
+	resp, err = client.Put(http.Body(nil), http.IfMatch(etag)).Send(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	assert.Equal(t, `"1"`, resp.ETag())
+
+	// the resource has changed since etag was read
+	resp, err = client.Put(http.Body(nil), http.IfMatch(etag)).Send(ctx)
+	assert.True(t, errors.Is(err, http.ErrPreconditionFailed))
+	assert.Equal(t, http.StatusPreconditionFailed, resp.StatusCode)
+
+	var statusErr *http.StatusError
+	require.True(t, errors.As(err, &statusErr))
+	assert.Equal(t, http.StatusPreconditionFailed, statusErr.StatusCode)
+
+	// unquoted etags are quoted
+	resp, err = client.Get(http.IfNoneMatch("1")).Send(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotModified, resp.StatusCode)
+}
+
+func TestConditional_Headers(t *testing.T) {
+	client := http.NewClient(http.URLString("https://example.com"))
+	since := time.Date(2021, 1, 1, 12, 0, 0, 0, time.FixedZone("CET", 3600))
+
+	req := client.Get(
+		http.IfModifiedSince(since),
+		http.IfUnmodifiedSince(since),
+		http.IfNoneMatch("*"),
+		http.IfMatch(`W/"abc"`),
+	)
+	require.NoError(t, req.Error())
+	assert.Equal(t, "Fri, 01 Jan 2021 11:00:00 GMT", req.Headers.Get("If-Modified-Since"))
+	assert.Equal(t, "Fri, 01 Jan 2021 11:00:00 GMT", req.Headers.Get("If-Unmodified-Since"))
+	assert.Equal(t, "*", req.Headers.Get("If-None-Match"))
+	assert.Equal(t, `W/"abc"`, req.Headers.Get("If-Match"))
+
+	assert.EqualError(t, client.Put(http.IfMatch("")).Error(), "cannot use empty etag in If-Match header")
+	assert.EqualError(t, client.Get(http.IfModifiedSince(time.Time{})).Error(), "cannot use zero time in If-Modified-Since header")
+}
@@ -0,0 +1,404 @@
+package http
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	stdhttp "net/http"
+	"strings"
+	"sync"
+)
+
+type DigestAuthOption struct {
+	digest *digestAuth
+}
+
+// DigestAuth is an option to authenticate requests with HTTP Digest authentication (RFC 7616),
+// using the MD5 and SHA-256 algorithms with qop=auth.
+//
+// When a request is rejected with 401 Unauthorized and a Digest challenge, the request is re-sent with
+// the response to the challenge. Request bodies without a GetBody function are kept in memory as they are sent,
+// up to 1MiB, so that they can be re-sent. The nonce is kept for each host so that later requests are authenticated straight away
+func DigestAuth(username, password string) DigestAuthOption {
+	return DigestAuthOption{&digestAuth{
+		username:   username,
+		password:   password,
+		challenges: make(map[string]*digestChallenge),
+	}}
+}
+
+func (o DigestAuthOption) ModifyClient(c *Client) {
+	TransportMiddlewares(o.digest.middleware).ModifyClient(c)
+}
+
+type digestAuth struct {
+	username string
+	password string
+
+	mu sync.Mutex
+	// challenges are the last challenges by host
+	challenges map[string]*digestChallenge
+
+	cnonce func() (string, error)
+}
+
+type digestChallenge struct {
+	realm     string
+	nonce     string
+	opaque    string
+	algorithm string
+	// qop is empty for servers that only support RFC 2069
+	qop      string
+	userhash bool
+	// nc is the number of requests sent with the nonce
+	nc uint32
+}
+
+func (d *digestAuth) middleware(next Doer) Doer {
+	return DoerFunc(func(req *stdhttp.Request) (*stdhttp.Response, error) {
+		return d.do(next, req)
+	})
+}
+
+func (d *digestAuth) do(next Doer, req *stdhttp.Request) (*stdhttp.Response, error) {
+	ctx := req.Context()
+	host := req.URL.Host
+	d.mu.Lock()
+	cached := d.challenges[host]
+	d.mu.Unlock()
+
+	sent := req.Clone(ctx)
+	if cached != nil {
+		authorization, err := d.authorize(cached, req)
+		if err != nil {
+			return nil, err
+		}
+		sent.Header.Set("Authorization", authorization)
+	}
+	var replay *replayBody
+	if !canResend(req) {
+		replay = &replayBody{ReadCloser: req.Body, contentLength: req.ContentLength}
+		sent.Body = replay
+	}
+	resp, err := next.Do(sent)
+	if err != nil || resp.StatusCode != stdhttp.StatusUnauthorized {
+		return resp, err
+	}
+
+	challenge := parseDigestChallenge(resp.Header.Values("WWW-Authenticate"))
+	// the same nonce being rejected again means the credentials are wrong
+	if challenge == nil || (cached != nil && challenge.nonce == cached.nonce) {
+		return resp, nil
+	}
+	d.mu.Lock()
+	d.challenges[host] = challenge
+	d.mu.Unlock()
+
+	getBody := req.GetBody
+	if replay != nil {
+		if getBody = replay.getBody(); getBody == nil {
+			// the body cannot be sent again
+			return resp, nil
+		}
+	}
+
+	authorization, err := d.authorize(challenge, req)
+	if err != nil {
+		return nil, err
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	_ = resp.Body.Close()
+
+	authed := req.Clone(ctx)
+	authed.Header.Set("Authorization", authorization)
+	if getBody != nil {
+		authed.GetBody = getBody
+		if authed.Body, err = getBody(); err != nil {
+			return nil, err
+		}
+	}
+	return next.Do(authed)
+}
+
+// maxDigestReplay is the size of the largest request body without a GetBody function
+// that is kept in memory, to re-send it after a challenge
+const maxDigestReplay = 1 << 20
+
+// replayBody keeps a copy of a request body as it is read, up to maxDigestReplay bytes
+type replayBody struct {
+	io.ReadCloser
+	contentLength int64
+
+	mu       sync.Mutex
+	buf      bytes.Buffer
+	overflow bool
+	eof      bool
+}
+
+func (b *replayBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.overflow {
+		if b.buf.Len()+n > maxDigestReplay {
+			b.overflow = true
+			b.buf = bytes.Buffer{}
+		} else {
+			b.buf.Write(p[:n])
+		}
+	}
+	b.eof = b.eof || err == io.EOF
+	return n, err
+}
+
+// getBody returns a function replaying the body, or nil if it was not read completely or was too large
+func (b *replayBody) getBody() func() (io.ReadCloser, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	// the transport stops reading at the content length, without necessarily reaching EOF
+	complete := b.eof || (b.contentLength > 0 && int64(b.buf.Len()) == b.contentLength)
+	if b.overflow || !complete {
+		return nil
+	}
+	data := b.buf.Bytes()
+	return func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+}
+
+// authorize returns the Authorization header answering the challenge for the request
+func (d *digestAuth) authorize(c *digestChallenge, req *stdhttp.Request) (string, error) {
+	var newHash func() hash.Hash
+	algorithm := strings.ToUpper(c.algorithm)
+	switch strings.TrimSuffix(algorithm, "-SESS") {
+	case "", "MD5":
+		newHash = md5.New
+	case "SHA-256":
+		newHash = sha256.New
+	default:
+		return "", fmt.Errorf("unsupported digest algorithm %s", c.algorithm)
+	}
+	h := func(s string) string {
+		hash := newHash()
+		_, _ = io.WriteString(hash, s)
+		return hex.EncodeToString(hash.Sum(nil))
+	}
+
+	newCnonce := randomCnonce
+	if d.cnonce != nil {
+		newCnonce = d.cnonce
+	}
+	cnonce, err := newCnonce()
+	if err != nil {
+		return "", err
+	}
+
+	d.mu.Lock()
+	c.nc++
+	nc := fmt.Sprintf("%08x", c.nc)
+	d.mu.Unlock()
+
+	uri := req.URL.RequestURI()
+	ha1 := h(d.username + ":" + c.realm + ":" + d.password)
+	if strings.HasSuffix(algorithm, "-SESS") {
+		ha1 = h(ha1 + ":" + c.nonce + ":" + cnonce)
+	}
+	ha2 := h(req.Method + ":" + uri)
+
+	var response string
+	if c.qop == "" {
+		response = h(ha1 + ":" + c.nonce + ":" + ha2)
+	} else {
+		response = h(strings.Join([]string{ha1, c.nonce, nc, cnonce, c.qop, ha2}, ":"))
+	}
+
+	username := "username=" + quoteString(d.username)
+	if c.userhash {
+		username = "username=" + quoteString(h(d.username+":"+c.realm))
+	} else if !isQuotable(d.username) {
+		// RFC 7616, 3.4.4
+		username = "username*=UTF-8''" + encodeExtValue(d.username)
+	}
+
+	params := []string{
+		username,
+		"realm=" + quoteString(c.realm),
+		"nonce=" + quoteString(c.nonce),
+		"uri=" + quoteString(uri),
+		"response=" + quoteString(response),
+	}
+	if c.algorithm != "" {
+		params = append(params, "algorithm="+c.algorithm)
+	}
+	if c.opaque != "" {
+		params = append(params, "opaque="+quoteString(c.opaque))
+	}
+	if c.qop != "" {
+		params = append(params, "qop="+c.qop, "nc="+nc, "cnonce="+quoteString(cnonce))
+	}
+	if c.userhash {
+		params = append(params, "userhash=true")
+	}
+	return "Digest " + strings.Join(params, ", "), nil
+}
+
+// quoteString returns s as a quoted-string (RFC 9110, 5.6.4)
+func quoteString(s string) string {
+	return `"` + quoteEscaper.Replace(s) + `"`
+}
+
+// unquoteString returns the content of a quoted-string, or s itself if it is a token
+func unquoteString(s string) string {
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return s
+	}
+	s = s[1 : len(s)-1]
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// isQuotable reports whether s only has visible ASCII characters, spaces and tabs,
+// so that it can be sent in a quoted-string
+func isQuotable(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; (c < ' ' && c != '\t') || c > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// encodeExtValue percent-encodes s for an ext-value (RFC 8187, 3.2.1)
+func encodeExtValue(s string) string {
+	const hexDigits = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || strings.IndexByte("!#$&+-.^_`|~", c) >= 0 {
+			b.WriteByte(c)
+		} else {
+			b.WriteByte('%')
+			b.WriteByte(hexDigits[c>>4])
+			b.WriteByte(hexDigits[c&15])
+		}
+	}
+	return b.String()
+}
+
+func randomCnonce() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// digestAlgorithms are the supported algorithms, most preferred first
+var digestAlgorithms = []string{"SHA-256", "SHA-256-SESS", "MD5", "MD5-SESS", ""}
+
+// parseDigestChallenge returns the supported Digest challenge with the most preferred algorithm
+// from the WWW-Authenticate headers, or nil if there is none
+func parseDigestChallenge(headers []string) *digestChallenge {
+	var best *digestChallenge
+	rank := len(digestAlgorithms)
+	for _, challenge := range parseChallenges(headers) {
+		if !strings.EqualFold(challenge.scheme, "digest") {
+			continue
+		}
+
+		c := &digestChallenge{}
+		qops := ""
+		for _, param := range challenge.params {
+			eq := strings.IndexByte(param, '=')
+			if eq < 0 {
+				continue
+			}
+			value := unquoteString(strings.TrimSpace(param[eq+1:]))
+			switch strings.ToLower(strings.TrimSpace(param[:eq])) {
+			case "realm":
+				c.realm = value
+			case "nonce":
+				c.nonce = value
+			case "opaque":
+				c.opaque = value
+			case "algorithm":
+				c.algorithm = value
+			case "qop":
+				qops = value
+			case "userhash":
+				c.userhash = strings.EqualFold(value, "true")
+			}
+		}
+		if c.nonce == "" {
+			continue
+		}
+		if qops != "" {
+			for _, qop := range strings.Split(qops, ",") {
+				if strings.TrimSpace(qop) == "auth" {
+					c.qop = "auth"
+				}
+			}
+			// only auth-int is offered
+			if c.qop == "" {
+				continue
+			}
+		}
+
+		for i, algorithm := range digestAlgorithms {
+			if strings.EqualFold(c.algorithm, algorithm) && i < rank {
+				best, rank = c, i
+			}
+		}
+	}
+	return best
+}
+
+// authChallenge is a challenge of a WWW-Authenticate header, with its unparsed parameters
+type authChallenge struct {
+	scheme string
+	params []string
+}
+
+// parseChallenges splits the WWW-Authenticate headers into challenges (RFC 9110, 11.6.1).
+// A header can hold several comma separated challenges, each starting with its scheme
+func parseChallenges(headers []string) []authChallenge {
+	var challenges []authChallenge
+	for _, header := range headers {
+		for _, element := range splitOutsideQuotes(header, ',') {
+			element = strings.TrimSpace(element)
+			if element == "" {
+				continue
+			}
+			// a scheme is a token followed by whitespace and a parameter, or on its own
+			word, rest := element, ""
+			if i := strings.IndexAny(element, " \t"); i >= 0 {
+				word, rest = element[:i], strings.TrimSpace(element[i:])
+			}
+			isScheme := !strings.Contains(word, "=") && !strings.HasPrefix(rest, "=")
+			switch {
+			case isScheme:
+				challenge := authChallenge{scheme: word}
+				if rest != "" {
+					challenge.params = []string{rest}
+				}
+				challenges = append(challenges, challenge)
+			case len(challenges) > 0:
+				last := &challenges[len(challenges)-1]
+				last.params = append(last.params, element)
+			}
+		}
+	}
+	return challenges
+}
@@ -0,0 +1,220 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	stdhttp "net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rfc7616Server challenges requests with the example of RFC 7616, 3.9.1,
+// responding with the Authorization header once it is given
+func rfc7616Server(algorithms ...string) *httptest.Server {
+	return httptest.NewServer(stdhttp.HandlerFunc(func(w stdhttp.ResponseWriter, r *stdhttp.Request) {
+		if auth := r.Header.Get("Authorization"); auth != "" {
+			_, _ = io.WriteString(w, auth)
+			return
+		}
+		for _, algorithm := range algorithms {
+			w.Header().Add("WWW-Authenticate", `Digest realm="http-auth@example.org", qop="auth, auth-int", algorithm=`+algorithm+
+				`, nonce="7ypf/xlj9XXwfDPEoM4URrv/xwf94BcCAzFZH4GiTo0v", opaque="FQhe/qaU925kfnzjCev0ciny7QMkPqMAFRtzCUYo5tdS"`)
+		}
+		w.WriteHeader(stdhttp.StatusUnauthorized)
+	}))
+}
+
+func TestDigestAuth_RFC7616(t *testing.T) {
+	tests := []struct {
+		name       string
+		algorithms []string
+		response   string
+	}{
+		{"MD5", []string{"MD5"}, "8ca523f5e9506fed4657c9700eebdbec"},
+		{"SHA-256", []string{"MD5", "SHA-256"}, "753927fa0e85d155564e2e272a28d1802ca10daf4496794697cf8db5856cb6c1"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := rfc7616Server(test.algorithms...)
+			defer server.Close()
+
+			digest := DigestAuth("Mufasa", "Circle of Life")
+			digest.digest.cnonce = func() (string, error) {
+				return "f2/wE4q74E6zIJEtWaHKaf5wv/H5QzzpXusqGemxURZJ", nil
+			}
+			client := NewClient(URLString(server.URL+"/dir/index.html"), digest)
+
+			resp, err := client.Get().Send(context.Background())
+			require.NoError(t, err)
+			b, err := io.ReadAll(resp)
+			require.NoError(t, err)
+			assert.Equal(t, `Digest username="Mufasa", realm="http-auth@example.org", `+
+				`nonce="7ypf/xlj9XXwfDPEoM4URrv/xwf94BcCAzFZH4GiTo0v", uri="/dir/index.html", `+
+				`response="`+test.response+`", algorithm=`+test.name+`, `+
+				`opaque="FQhe/qaU925kfnzjCev0ciny7QMkPqMAFRtzCUYo5tdS", qop=auth, nc=00000001, `+
+				`cnonce="f2/wE4q74E6zIJEtWaHKaf5wv/H5QzzpXusqGemxURZJ"`, string(b))
+		})
+	}
+}
+
+func TestParseDigestChallenge_SingleHeader(t *testing.T) {
+	// several challenges in one header do not have their parameters merged
+	c := parseDigestChallenge([]string{`Basic realm="basic", Digest realm="md5", nonce="n1", algorithm=MD5, ` +
+		`Digest realm="sha", nonce="n2", qop="auth", algorithm=SHA-256, Bearer`})
+	require.NotNil(t, c)
+	assert.Equal(t, "sha", c.realm)
+	assert.Equal(t, "n2", c.nonce)
+	assert.Equal(t, "auth", c.qop)
+	assert.Equal(t, "SHA-256", c.algorithm)
+
+	c = parseDigestChallenge([]string{`Digest realm="md5", nonce="n1", Basic realm="basic"`})
+	require.NotNil(t, c)
+	assert.Equal(t, "md5", c.realm)
+	assert.Equal(t, "", c.algorithm)
+}
+
+func TestDigestAuth_QuotedStrings(t *testing.T) {
+	// quoted-pairs can escape any character
+	c := parseDigestChallenge([]string{`Digest realm="a \\ \"b\" \c", nonce="n1"`})
+	require.NotNil(t, c)
+	assert.Equal(t, `a \ "b" c`, c.realm)
+
+	req, err := stdhttp.NewRequest("GET", "https://example.com/", nil)
+	require.NoError(t, err)
+	auth, err := DigestAuth("a\tb", "pass").digest.authorize(c, req)
+	require.NoError(t, err)
+	assert.Contains(t, auth, "username=\"a\tb\", realm="+`"a \\ \"b\" c"`)
+
+	// usernames that cannot be quoted are encoded as in RFC 7616, 3.9.2
+	auth, err = DigestAuth("J\u00e4s\u00f8n Doe", "pass").digest.authorize(c, req)
+	require.NoError(t, err)
+	assert.Contains(t, auth, `Digest username*=UTF-8''J%C3%A4s%C3%B8n%20Doe, realm=`)
+}
+
+// digestServer checks MD5 digest credentials for user:pass, responding with the nonce count and the size of the request body.
+// The nonce is replaced by incrementing nonce
+type digestServer struct {
+	*httptest.Server
+
+	mu         sync.Mutex
+	nonce      int
+	challenges int
+}
+
+func newDigestServer(t *testing.T) *digestServer {
+	s := &digestServer{}
+	s.Server = httptest.NewServer(stdhttp.HandlerFunc(func(w stdhttp.ResponseWriter, r *stdhttp.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		nonce := fmt.Sprintf("nonce%d", s.nonce)
+		b, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		params := map[string]string{}
+		if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Digest ") {
+			for _, param := range strings.Split(auth[7:], ", ") {
+				kv := strings.SplitN(param, "=", 2)
+				params[kv[0]] = strings.Trim(kv[1], `"`)
+			}
+		}
+		h := func(s string) string {
+			sum := md5.Sum([]byte(s))
+			return hex.EncodeToString(sum[:])
+		}
+		ha1 := h("user:realm:pass")
+		ha2 := h(r.Method + ":" + r.URL.RequestURI())
+		expected := h(strings.Join([]string{ha1, nonce, params["nc"], params["cnonce"], "auth", ha2}, ":"))
+
+		if params["nonce"] != nonce || params["uri"] != r.URL.RequestURI() || params["response"] != expected {
+			s.challenges++
+			w.Header().Set("WWW-Authenticate", `Digest realm="realm", qop="auth", nonce="`+nonce+`"`)
+			w.WriteHeader(stdhttp.StatusUnauthorized)
+			return
+		}
+		fmt.Fprintf(w, "%s %d", params["nc"], len(b))
+	}))
+	return s
+}
+
+// onlyReader hides the type of the reader so that it cannot be replayed
+type onlyReader struct {
+	io.Reader
+}
+
+func TestDigestAuth(t *testing.T) {
+	server := newDigestServer(t)
+	defer server.Close()
+
+	client := NewClient(URLString(server.URL+"/path?q=1"), DigestAuth("user", "pass"))
+	ctx := context.Background()
+	send := func() string {
+		t.Helper()
+		resp, err := client.Post(Body(onlyReader{strings.NewReader("body")})).Send(ctx)
+		require.NoError(t, err)
+		b, err := io.ReadAll(resp)
+		require.NoError(t, err)
+		return string(b)
+	}
+
+	// the body is re-sent after the challenge
+	assert.Equal(t, "00000001 4", send())
+	assert.Equal(t, 1, server.challenges)
+
+	// the nonce is cached
+	assert.Equal(t, "00000002 4", send())
+	assert.Equal(t, 1, server.challenges)
+
+	// a new nonce is used once the old one is rejected
+	server.mu.Lock()
+	server.nonce++
+	server.mu.Unlock()
+	assert.Equal(t, "00000001 4", send())
+	assert.Equal(t, 2, server.challenges)
+}
+
+func TestDigestAuth_LargeBody(t *testing.T) {
+	server := newDigestServer(t)
+	defer server.Close()
+
+	client := NewClient(URLString(server.URL), DigestAuth("user", "pass"))
+	send := func(size int) *Response {
+		t.Helper()
+		resp, err := client.Post(Body(onlyReader{bytes.NewReader(make([]byte, size))})).Send(context.Background())
+		require.NoError(t, err)
+		return resp
+	}
+
+	// bodies too large to keep in memory are not re-sent
+	assert.Equal(t, StatusUnauthorized, send(maxDigestReplay+1).StatusCode)
+
+	// but the nonce is cached, so later requests are authenticated straight away
+	resp := send(maxDigestReplay + 1)
+	assert.Equal(t, StatusOK, resp.StatusCode)
+	b, err := io.ReadAll(resp)
+	require.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("00000001 %d", maxDigestReplay+1), string(b))
+	assert.Equal(t, 1, server.challenges)
+}
+
+func TestDigestAuth_WrongPassword(t *testing.T) {
+	server := newDigestServer(t)
+	defer server.Close()
+
+	client := NewClient(URLString(server.URL), DigestAuth("user", "wrong"))
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get().Send(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, StatusUnauthorized, resp.StatusCode)
+	}
+	// the rejected nonce is not retried with the same credentials
+	assert.Equal(t, 3, server.challenges)
+}
@@ -0,0 +1,275 @@
+package http
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrChecksumMismatch is returned when a downloaded file does not match its expected digest
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+type DownloadOption struct {
+	path     string
+	progress func(written, total int64)
+	sha256   string
+	attempts int
+}
+
+// DownloadTo is an option to write the response body into a file at path.
+// The file is written to a temporary file in the same directory first,
+// which is only renamed to path once the whole body has been written and verified.
+// The body is not kept in memory.
+//
+// If the response has a SHA-256 Digest or Repr-Digest header, the file is verified against it
+func DownloadTo(path string) DownloadOption {
+	return DownloadOption{path: path, attempts: 3}
+}
+
+// Progress returns a copy of the option that calls fn as the file is written.
+// total is -1 if the size of the file is not known
+func (d DownloadOption) Progress(fn func(written, total int64)) DownloadOption {
+	d.progress = fn
+	return d
+}
+
+// SHA256 returns a copy of the option that verifies the file against the hex encoded SHA-256 digest
+func (d DownloadOption) SHA256(digest string) DownloadOption {
+	d.sha256 = strings.ToLower(digest)
+	return d
+}
+
+// Attempts returns a copy of the option that makes up to n attempts when used with Client.Download
+func (d DownloadOption) Attempts(n int) DownloadOption {
+	d.attempts = n
+	return d
+}
+
+func (d DownloadOption) ProcessResponse(resp *Response) error {
+	dl := &download{opt: d}
+	defer dl.cleanup()
+
+	if err := dl.ProcessResponse(resp); err != nil {
+		return err
+	}
+	return dl.finish()
+}
+
+// Download sends GET requests with the options provided, writing the response body into a file as DownloadTo does.
+// If the transfer fails part way through, the request is re-sent with Range and If-Range headers
+// to resume the download where it stopped, up to the number of attempts of the option
+func (c *Client) Download(ctx context.Context, d DownloadOption, options ...RequestOption) (*Response, error) {
+	dl := &download{opt: d}
+	defer dl.cleanup()
+
+	for attempt := 1; ; attempt++ {
+		opts := options[:len(options):len(options)]
+		if dl.written > 0 && dl.validator != "" {
+			opts = append(opts,
+				SetHeader("Range", fmt.Sprintf("bytes=%d-", dl.written)),
+				SetHeader("If-Range", dl.validator),
+			)
+		}
+
+		dl.retryable = false
+		resp, err := c.Get(opts...).Send(ctx, dl)
+		if err == nil {
+			return resp, dl.finish()
+		}
+		if (resp != nil && !dl.retryable) || attempt >= d.attempts || ctx.Err() != nil {
+			return resp, err
+		}
+
+		timer := time.NewTimer(DefaultRetryPolicy.backoff(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// download is the state of a file being downloaded, kept between attempts
+type download struct {
+	opt DownloadOption
+
+	file    *os.File
+	hash    hash.Hash
+	written int64
+	total   int64
+
+	// validator is the strong ETag or Last-Modified date of the file, used to resume the download
+	validator string
+	// digest is the expected SHA-256 digest sent by the server
+	digest []byte
+
+	// retryable is set if the last response failed while writing the body
+	retryable bool
+	done      bool
+}
+
+func (dl *download) ProcessResponse(resp *Response) error {
+	switch resp.StatusCode {
+	case StatusOK:
+		if err := dl.restart(); err != nil {
+			return err
+		}
+		dl.total = resp.ContentLength
+	case StatusPartialContent:
+		start, total, err := parseContentRange(resp.Headers.Get("Content-Range"))
+		if err != nil {
+			return err
+		}
+		if start != dl.written || dl.file == nil {
+			return fmt.Errorf("cannot resume download at %d from content range starting at %d", dl.written, start)
+		}
+		dl.total = total
+	default:
+		return ExpectStatus(StatusOK, StatusPartialContent).ProcessResponse(resp)
+	}
+
+	dl.validator = ""
+	if etag := resp.Headers.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		dl.validator = etag
+	} else if lm := resp.Headers.Get("Last-Modified"); lm != "" {
+		dl.validator = lm
+	}
+	if digest := parseSHA256Digest(resp); digest != nil {
+		dl.digest = digest
+	}
+
+	resp.body.unbuffered = true
+	_, err := io.Copy(dl, resp.body)
+	if err != nil {
+		dl.retryable = true
+		return fmt.Errorf("cannot download file: %w", err)
+	}
+	return nil
+}
+
+// restart truncates the temporary file, creating it if needed
+func (dl *download) restart() error {
+	dl.written = 0
+	dl.hash = sha256.New()
+	dl.digest = nil
+	if dl.file != nil {
+		if _, err := dl.file.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		return dl.file.Truncate(0)
+	}
+
+	dir, base := filepath.Split(dl.opt.path)
+	if dir == "" {
+		dir = "."
+	}
+	f, err := os.CreateTemp(dir, "."+base+".*.part")
+	if err != nil {
+		return fmt.Errorf("cannot create download file: %w", err)
+	}
+	dl.file = f
+	return nil
+}
+
+func (dl *download) Write(p []byte) (int, error) {
+	n, err := dl.file.Write(p)
+	dl.hash.Write(p[:n])
+	dl.written += int64(n)
+	if dl.opt.progress != nil {
+		dl.opt.progress(dl.written, dl.total)
+	}
+	return n, err
+}
+
+// finish verifies the downloaded file and moves it into place
+func (dl *download) finish() error {
+	sum := dl.hash.Sum(nil)
+	if dl.opt.sha256 != "" && hex.EncodeToString(sum) != dl.opt.sha256 {
+		return fmt.Errorf("%w: expected sha256 %s, got %x", ErrChecksumMismatch, dl.opt.sha256, sum)
+	}
+	if dl.digest != nil && string(dl.digest) != string(sum) {
+		return fmt.Errorf("%w: expected sha256 digest %x, got %x", ErrChecksumMismatch, dl.digest, sum)
+	}
+
+	// temporary files are only readable by their owner
+	if err := dl.file.Chmod(0o644); err != nil {
+		return err
+	}
+	if err := dl.file.Sync(); err != nil {
+		return err
+	}
+	if err := dl.file.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(dl.file.Name(), dl.opt.path); err != nil {
+		return err
+	}
+	dl.done = true
+	return nil
+}
+
+// cleanup removes the temporary file if the download did not finish
+func (dl *download) cleanup() {
+	if dl.file == nil || dl.done {
+		return
+	}
+	_ = dl.file.Close()
+	_ = os.Remove(dl.file.Name())
+}
+
+// parseContentRange parses a "bytes start-end/total" Content-Range header. total is -1 if unknown
+func parseContentRange(header string) (start, total int64, err error) {
+	invalid := fmt.Errorf("invalid Content-Range header %q", header)
+
+	spec := strings.TrimPrefix(header, "bytes ")
+	if spec == header {
+		return 0, 0, invalid
+	}
+	slash := strings.IndexByte(spec, '/')
+	dash := strings.IndexByte(spec, '-')
+	if slash < 0 || dash < 0 || dash > slash {
+		return 0, 0, invalid
+	}
+	if start, err = strconv.ParseInt(spec[:dash], 10, 64); err != nil {
+		return 0, 0, invalid
+	}
+	total = -1
+	if spec[slash+1:] != "*" {
+		if total, err = strconv.ParseInt(spec[slash+1:], 10, 64); err != nil {
+			return 0, 0, invalid
+		}
+	}
+	return start, total, nil
+}
+
+// parseSHA256Digest returns the SHA-256 digest from a Digest (RFC 3230) or Repr-Digest (RFC 9530) header
+func parseSHA256Digest(resp *Response) []byte {
+	for _, header := range []string{"Repr-Digest", "Digest"} {
+		for _, v := range resp.Headers.Values(header) {
+			for _, d := range strings.Split(v, ",") {
+				d = strings.TrimSpace(d)
+				eq := strings.IndexByte(d, '=')
+				if eq < 0 || !strings.EqualFold(d[:eq], "sha-256") {
+					continue
+				}
+				// Repr-Digest uses structured field byte sequences, wrapped in colons
+				value := strings.Trim(d[eq+1:], ":")
+				if b, err := base64.StdEncoding.DecodeString(value); err == nil && len(b) == sha256.Size {
+					return b
+				}
+			}
+		}
+	}
+	return nil
+}
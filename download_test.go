@@ -0,0 +1,123 @@
+package http_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	stdhttp "net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/conradludgate/go-http"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fileServer serves content with an ETag, failing part way through the first request
+func fileServer(t *testing.T, content []byte, ranges *[]string) *httptest.Server {
+	var mu sync.Mutex
+	sum := sha256.Sum256(content)
+	return httptest.NewServer(stdhttp.HandlerFunc(func(w stdhttp.ResponseWriter, r *stdhttp.Request) {
+		mu.Lock()
+		*ranges = append(*ranges, r.Header.Get("Range"))
+		first := len(*ranges) == 1
+		mu.Unlock()
+
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Digest", "SHA-256="+base64.StdEncoding.EncodeToString(sum[:]))
+		if first {
+			w.Header().Set("Content-Length", "100000")
+			_, _ = w.Write(content[:40000])
+			w.(stdhttp.Flusher).Flush()
+			conn, _, err := w.(stdhttp.Hijacker).Hijack()
+			require.NoError(t, err)
+			_ = conn.Close()
+			return
+		}
+		stdhttp.ServeContent(w, r, "file", time.Time{}, bytes.NewReader(content))
+	}))
+}
+
+func TestDownload_Resume(t *testing.T) {
+	content := make([]byte, 100000)
+	_, err := rand.Read(content)
+	require.NoError(t, err)
+	sum := sha256.Sum256(content)
+
+	var ranges []string
+	server := fileServer(t, content, &ranges)
+	defer server.Close()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "file.bin")
+
+	var written, total int64
+	client := http.NewClient(http.URLString(server.URL))
+	resp, err := client.Download(context.Background(),
+		http.DownloadTo(path).
+			SHA256(hex.EncodeToString(sum[:])).
+			Progress(func(w, t int64) { written, total = w, t }),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusPartialContent, resp.StatusCode)
+	assert.Equal(t, []string{"", "bytes=40000-"}, ranges)
+	assert.EqualValues(t, 100000, written)
+	assert.EqualValues(t, 100000, total)
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, content, b)
+
+	// only the downloaded file is left
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestDownload_ChecksumMismatch(t *testing.T) {
+	server := httptest.NewServer(stdhttp.HandlerFunc(func(w stdhttp.ResponseWriter, r *stdhttp.Request) {
+		sum := sha256.Sum256([]byte("something else"))
+		w.Header().Set("Repr-Digest", "sha-256=:"+base64.StdEncoding.EncodeToString(sum[:])+":")
+		_, _ = w.Write([]byte("file content"))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "file.txt")
+
+	client := http.NewClient(http.URLString(server.URL))
+	_, err := client.Get().Send(context.Background(), http.DownloadTo(path))
+	assert.True(t, errors.Is(err, http.ErrChecksumMismatch))
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	_, err = client.Get().Send(context.Background(), http.DownloadTo(path).SHA256("00"))
+	assert.True(t, errors.Is(err, http.ErrChecksumMismatch))
+}
+
+func TestDownloadTo(t *testing.T) {
+	server := httptest.NewServer(stdhttp.HandlerFunc(func(w stdhttp.ResponseWriter, r *stdhttp.Request) {
+		_, _ = w.Write([]byte("file content"))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "file.txt")
+	require.NoError(t, os.WriteFile(path, []byte("old content"), 0o644))
+
+	_, err := http.NewClient(http.URLString(server.URL)).Get().Send(context.Background(), http.DownloadTo(path))
+	require.NoError(t, err)
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "file content", string(b))
+}
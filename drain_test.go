@@ -0,0 +1,83 @@
+package http_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	stdhttp "net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/conradludgate/go-http"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// connServer counts the connections opened to it, responding with size bytes
+func connServer(size int, conns *int32) *httptest.Server {
+	body := bytes.Repeat([]byte("x"), size)
+	server := httptest.NewUnstartedServer(stdhttp.HandlerFunc(func(w stdhttp.ResponseWriter, r *stdhttp.Request) {
+		_, _ = w.Write(body)
+	}))
+	server.Config.ConnState = func(c net.Conn, state stdhttp.ConnState) {
+		if state == stdhttp.StateNew {
+			atomic.AddInt32(conns, 1)
+		}
+	}
+	server.Start()
+	return server
+}
+
+func TestSend_DrainsBody(t *testing.T) {
+	var conns int32
+	server := connServer(64<<10, &conns)
+	defer server.Close()
+
+	client := http.NewClient(http.URLString(server.URL), http.BaseClient(server.Client()))
+	ctx := context.Background()
+
+	for i := 0; i < 10; i++ {
+		resp, err := client.Get().Send(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+	assert.EqualValues(t, 1, atomic.LoadInt32(&conns))
+
+	// the drained body can still be read
+	resp, err := client.Get().Send(ctx)
+	require.NoError(t, err)
+	b, err := io.ReadAll(resp)
+	require.NoError(t, err)
+	assert.Len(t, b, 64<<10)
+}
+
+func TestSend_DrainsLargeBody(t *testing.T) {
+	var conns int32
+	server := connServer(1<<20, &conns)
+	defer server.Close()
+
+	client := http.NewClient(http.URLString(server.URL), http.BaseClient(server.Client()))
+	ctx := context.Background()
+
+	// too large to drain, so the connection is closed instead of leaked
+	resp, err := client.Get().Send(ctx)
+	require.NoError(t, err)
+	_, err = io.ReadAll(resp)
+	assert.Error(t, err)
+
+	// keeping it open allows the whole body to be streamed
+	resp, err = client.Get().Send(ctx, http.Streaming(), http.KeepOpen())
+	require.NoError(t, err)
+	n, err := io.Copy(io.Discard, resp)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1<<20, n)
+	require.NoError(t, resp.Close())
+
+	resp, err = client.Get().Send(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	assert.EqualValues(t, 2, atomic.LoadInt32(&conns))
+}
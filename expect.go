@@ -0,0 +1,110 @@
+package http
+
+import (
+	"fmt"
+	"io"
+	stdhttp "net/http"
+)
+
+// maxErrorBodySize is the number of bytes of the response body kept in a StatusError
+const maxErrorBodySize = 4096
+
+// StatusError is returned when a response has an unexpected status code
+type StatusError struct {
+	StatusCode Status
+	Headers    stdhttp.Header
+	// Body is the start of the response body, up to 4KiB
+	Body []byte
+	// Value is the decoded error body, if the response was processed with ErrorJSON
+	Value interface{}
+}
+
+func (e *StatusError) Error() string {
+	if err, ok := e.Value.(error); ok {
+		return fmt.Sprintf("unexpected response status: %s: %s", e.StatusCode, err)
+	}
+	if len(e.Body) == 0 {
+		return fmt.Sprintf("unexpected response status: %s", e.StatusCode)
+	}
+	return fmt.Sprintf("unexpected response status: %s: %s", e.StatusCode, e.Body)
+}
+
+// Unwrap returns the decoded error body if it implements error,
+// so it can be retrieved using errors.As
+func (e *StatusError) Unwrap() error {
+	if err, ok := e.Value.(error); ok {
+		return err
+	}
+	return nil
+}
+
+// Is reports whether the error matches target. A 412 Precondition Failed status matches ErrPreconditionFailed
+func (e *StatusError) Is(target error) bool {
+	return target == ErrPreconditionFailed && e.StatusCode == StatusPreconditionFailed
+}
+
+// StatusType returns the class of the unexpected status code
+func (e *StatusError) StatusType() StatusType {
+	return e.StatusCode.Type()
+}
+
+// newStatusError snapshots the response into a StatusError.
+// The response body is reset afterwards so it can still be read
+func newStatusError(resp *Response) (*StatusError, error) {
+	body, err := io.ReadAll(io.LimitReader(resp, maxErrorBodySize))
+	if err != nil {
+		return nil, err
+	}
+	_ = resp.Reset() // best effort, a streamed body cannot be read again anyway
+	return &StatusError{
+		StatusCode: resp.StatusCode,
+		Headers:    resp.Headers,
+		Body:       body,
+	}, nil
+}
+
+type ExpectStatusOption struct {
+	statuses []Status
+	types    []StatusType
+}
+
+// ExpectStatus is an option to fail a response with a *StatusError
+// if its status code is not one of the provided statuses
+func ExpectStatus(statuses ...Status) ExpectStatusOption {
+	return ExpectStatusOption{statuses: statuses}
+}
+
+// ExpectSuccess is an option to fail a response with a *StatusError
+// if its status code is not a 2xx status
+func ExpectSuccess() ExpectStatusOption {
+	return ExpectStatusOption{types: []StatusType{StatusTypeSuccess}}
+}
+
+func (e ExpectStatusOption) matches(status Status) bool {
+	for _, s := range e.statuses {
+		if s == status {
+			return true
+		}
+	}
+	for _, t := range e.types {
+		if t == status.Type() {
+			return true
+		}
+	}
+	return false
+}
+
+func (e ExpectStatusOption) ProcessResponse(resp *Response) error {
+	if e.matches(resp.StatusCode) {
+		return nil
+	}
+	statusErr, err := newStatusError(resp)
+	if err != nil {
+		return fmt.Errorf("cannot read response body: %w", err)
+	}
+	return statusErr
+}
+
+func (e ExpectStatusOption) ModifyClient(c *Client) {
+	PreResponseMiddlewares(e).ModifyClient(c)
+}
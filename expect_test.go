@@ -0,0 +1,67 @@
+package http_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/conradludgate/go-http"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpectStatus(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "https://example.com/api/missing",
+		httpmock.NewStringResponder(404, "<html>not found</html>"))
+	httpmock.RegisterResponder("GET", "https://example.com/api/created",
+		httpmock.NewStringResponder(201, "created"))
+
+	client := http.NewClient(http.URLString("https://example.com/api"))
+	ctx := context.Background()
+
+	var respBody FooBar
+	resp, err := client.Get(http.Path("missing")).Send(ctx,
+		http.ExpectStatus(http.StatusOK, http.StatusCreated),
+		http.JSON(&respBody),
+	)
+	assert.EqualError(t, err, "unexpected response status: 404 Not Found: <html>not found</html>")
+
+	var statusErr *http.StatusError
+	require.True(t, errors.As(err, &statusErr))
+	assert.Equal(t, http.StatusNotFound, statusErr.StatusCode)
+	assert.Equal(t, http.StatusTypeClientError, statusErr.StatusType())
+	assert.Equal(t, "<html>not found</html>", string(statusErr.Body))
+
+	// the body can still be read after the error
+	b, err := io.ReadAll(resp)
+	require.NoError(t, err)
+	assert.Equal(t, "<html>not found</html>", string(b))
+
+	_, err = client.Get(http.Path("created")).Send(ctx, http.ExpectStatus(http.StatusOK, http.StatusCreated))
+	assert.NoError(t, err)
+}
+
+func TestExpectSuccess_Client(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "https://example.com/api/error",
+		httpmock.NewStringResponder(503, ""))
+
+	client := http.NewClient(
+		http.URLString("https://example.com/api"),
+		http.ExpectSuccess(),
+	)
+
+	_, err := client.Get(http.Path("error")).Send(context.Background())
+	assert.EqualError(t, err, "unexpected response status: 503 Service Unavailable")
+
+	var statusErr *http.StatusError
+	require.True(t, errors.As(err, &statusErr))
+	assert.Equal(t, http.StatusTypeServerError, statusErr.StatusType())
+}
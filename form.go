@@ -0,0 +1,278 @@
+package http
+
+import (
+	"encoding"
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const formContentType = "application/x-www-form-urlencoded"
+
+type FormOption struct {
+	values url.Values
+}
+
+// Form is an option to add a URL encoded form body to a request or to expect a URL encoded form body in a response.
+// When decoding a response, the form values are added to values, which must not be nil
+func Form(values url.Values) FormOption {
+	return FormOption{values}
+}
+
+func (f FormOption) ModifyRequest(r *Request) error {
+	return r.applyOptions(Body(strings.NewReader(f.values.Encode())), SetHeader("Content-Type", formContentType))
+}
+
+func (f FormOption) ProcessResponse(resp *Response) error {
+	if f.values == nil {
+		return fmt.Errorf("cannot decode form into nil url.Values")
+	}
+	values, err := readForm(resp)
+	if err != nil {
+		return err
+	}
+	for k, vs := range values {
+		for _, v := range vs {
+			f.values.Add(k, v)
+		}
+	}
+	return nil
+}
+
+type FormStructOption struct {
+	v interface{}
+}
+
+// FormStruct is an option to add a URL encoded form body to a request from a struct,
+// or to decode a URL encoded form body from a response into a struct pointer.
+//
+// Fields are named by their `form` struct tag, or the field name if there is none.
+// The tag can include an omitempty option, and a tag of "-" skips the field.
+// Supported field types are strings, bools, numbers, encoding.TextMarshalers and slices or pointers of them.
+func FormStruct(v interface{}) FormStructOption {
+	return FormStructOption{v}
+}
+
+func (f FormStructOption) ModifyRequest(r *Request) error {
+	values, err := encodeForm(f.v)
+	if err != nil {
+		return fmt.Errorf("cannot encode request body: %w", err)
+	}
+	return Form(values).ModifyRequest(r)
+}
+
+func (f FormStructOption) ProcessResponse(resp *Response) error {
+	values, err := readForm(resp)
+	if err != nil {
+		return err
+	}
+	return decodeForm(values, f.v)
+}
+
+func readForm(resp *Response) (url.Values, error) {
+	ct := resp.Headers.Get("Content-Type")
+	if ct != "" {
+		if mediaType, _, _ := mime.ParseMediaType(ct); mediaType != formContentType {
+			return nil, fmt.Errorf("invalid Content-Type header, expected '%s', got %s", formContentType, ct)
+		}
+	}
+	b, err := io.ReadAll(resp)
+	if err != nil {
+		return nil, err
+	}
+	return url.ParseQuery(string(b))
+}
+
+type formField struct {
+	name      string
+	omitEmpty bool
+	index     []int
+}
+
+// formFields lists the fields of a struct type, flattening embedded structs
+func formFields(t reflect.Type) []formField {
+	var fields []formField
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("form")
+		if tag == "-" {
+			continue
+		}
+		if sf.Anonymous && sf.Type.Kind() == reflect.Struct && tag == "" {
+			for _, f := range formFields(sf.Type) {
+				f.index = append([]int{i}, f.index...)
+				fields = append(fields, f)
+			}
+			continue
+		}
+		if sf.PkgPath != "" {
+			continue // unexported
+		}
+
+		f := formField{name: sf.Name, index: []int{i}}
+		opts := strings.Split(tag, ",")
+		if opts[0] != "" {
+			f.name = opts[0]
+		}
+		for _, opt := range opts[1:] {
+			if opt == "omitempty" {
+				f.omitEmpty = true
+			}
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+func structValue(v interface{}) (reflect.Value, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return reflect.Value{}, fmt.Errorf("form: nil %s", rv.Type())
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return reflect.Value{}, fmt.Errorf("form: unsupported type %s", rv.Type())
+	}
+	return rv, nil
+}
+
+func encodeForm(v interface{}) (url.Values, error) {
+	rv, err := structValue(v)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(url.Values)
+	for _, f := range formFields(rv.Type()) {
+		fv := rv.FieldByIndex(f.index)
+		if f.omitEmpty && fv.IsZero() {
+			continue
+		}
+		if fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() != reflect.Uint8 {
+			for i := 0; i < fv.Len(); i++ {
+				s, err := formatFormValue(fv.Index(i))
+				if err != nil {
+					return nil, fmt.Errorf("form: field %s: %w", f.name, err)
+				}
+				values.Add(f.name, s)
+			}
+			continue
+		}
+		if fv.Kind() == reflect.Ptr && fv.IsNil() {
+			continue
+		}
+		s, err := formatFormValue(fv)
+		if err != nil {
+			return nil, fmt.Errorf("form: field %s: %w", f.name, err)
+		}
+		values.Add(f.name, s)
+	}
+	return values, nil
+}
+
+func formatFormValue(v reflect.Value) (string, error) {
+	if m, ok := v.Interface().(encoding.TextMarshaler); ok {
+		b, err := m.MarshalText()
+		return string(b), err
+	}
+	switch v.Kind() {
+	case reflect.Ptr:
+		return formatFormValue(v.Elem())
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits()), nil
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return string(v.Bytes()), nil
+		}
+	}
+	return "", fmt.Errorf("unsupported type %s", v.Type())
+}
+
+func decodeForm(values url.Values, v interface{}) error {
+	if rv := reflect.ValueOf(v); rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("form: decode target must be a non-nil pointer, got %T", v)
+	}
+	rv, err := structValue(v)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range formFields(rv.Type()) {
+		vs, ok := values[f.name]
+		if !ok {
+			continue
+		}
+		fv := rv.FieldByIndex(f.index)
+		if fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() != reflect.Uint8 {
+			slice := reflect.MakeSlice(fv.Type(), len(vs), len(vs))
+			for i, s := range vs {
+				if err := parseFormValue(slice.Index(i), s); err != nil {
+					return fmt.Errorf("form: field %s: %w", f.name, err)
+				}
+			}
+			fv.Set(slice)
+			continue
+		}
+		if len(vs) == 0 {
+			continue
+		}
+		if err := parseFormValue(fv, vs[0]); err != nil {
+			return fmt.Errorf("form: field %s: %w", f.name, err)
+		}
+	}
+	return nil
+}
+
+func parseFormValue(v reflect.Value, s string) error {
+	if v.CanAddr() {
+		if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+			return u.UnmarshalText([]byte(s))
+		}
+	}
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return parseFormValue(v.Elem(), s)
+	case reflect.String:
+		v.SetString(s)
+		return nil
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		v.SetBool(b)
+		return err
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		v.SetInt(n)
+		return err
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		v.SetUint(n)
+		return err
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, v.Type().Bits())
+		v.SetFloat(n)
+		return err
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			v.SetBytes([]byte(s))
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported type %s", v.Type())
+}
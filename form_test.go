@@ -0,0 +1,109 @@
+package http_test
+
+import (
+	"context"
+	stdhttp "net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/conradludgate/go-http"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func formResponder(t *testing.T, expected url.Values, response string) httpmock.Responder {
+	return func(req *stdhttp.Request) (*stdhttp.Response, error) {
+		assert.Equal(t, "application/x-www-form-urlencoded", req.Header.Get("Content-Type"))
+		require.NoError(t, req.ParseForm())
+		assert.Equal(t, expected, req.PostForm)
+
+		resp := httpmock.NewStringResponse(200, response)
+		resp.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+		return resp, nil
+	}
+}
+
+func TestForm(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("POST", "https://example.com/token", formResponder(t,
+		url.Values{"grant_type": {"client_credentials"}, "scope": {"a", "b"}},
+		"access_token=abc&expires_in=3600",
+	))
+
+	respBody := url.Values{}
+	_, err := http.NewClient().Post(
+		http.URLString("https://example.com/token"),
+		http.Form(url.Values{"grant_type": {"client_credentials"}, "scope": {"a", "b"}}),
+	).Send(context.Background(), http.Form(respBody))
+
+	require.NoError(t, err)
+	assert.Equal(t, url.Values{"access_token": {"abc"}, "expires_in": {"3600"}}, respBody)
+
+	_, err = http.NewClient().Post(
+		http.URLString("https://example.com/token"),
+		http.Form(url.Values{"grant_type": {"client_credentials"}, "scope": {"a", "b"}}),
+	).Send(context.Background(), http.Form(nil))
+	assert.EqualError(t, err, "cannot decode form into nil url.Values")
+}
+
+type TokenRequest struct {
+	GrantType string   `form:"grant_type"`
+	Scopes    []string `form:"scope"`
+	Audience  string   `form:"audience,omitempty"`
+	Internal  string   `form:"-"`
+}
+
+type TokenResponse struct {
+	AccessToken string    `form:"access_token"`
+	ExpiresIn   int       `form:"expires_in"`
+	Refreshable *bool     `form:"refreshable"`
+	Expiry      time.Time `form:"expiry"`
+}
+
+func TestFormStruct(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("POST", "https://example.com/token", formResponder(t,
+		url.Values{"grant_type": {"client_credentials"}, "scope": {"a", "b"}},
+		"access_token=abc&expires_in=3600&refreshable=true&expiry=2021-05-01T00:00:00Z",
+	))
+
+	var respBody TokenResponse
+	_, err := http.NewClient().Post(
+		http.URLString("https://example.com/token"),
+		http.FormStruct(TokenRequest{
+			GrantType: "client_credentials",
+			Scopes:    []string{"a", "b"},
+			Internal:  "secret",
+		}),
+	).Send(context.Background(), http.FormStruct(&respBody))
+
+	require.NoError(t, err)
+	assert.Equal(t, "abc", respBody.AccessToken)
+	assert.Equal(t, 3600, respBody.ExpiresIn)
+	require.NotNil(t, respBody.Refreshable)
+	assert.True(t, *respBody.Refreshable)
+	assert.Equal(t, time.Date(2021, 5, 1, 0, 0, 0, 0, time.UTC), respBody.Expiry)
+}
+
+func TestFormStruct_Errors(t *testing.T) {
+	err := http.NewClient().Post(
+		http.URLString("https://example.com/token"),
+		http.FormStruct(struct{ C chan int }{}),
+	).Error()
+	assert.EqualError(t, err, "cannot encode request body: form: field C: unsupported type chan int")
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder("GET", "https://example.com/json", httpmock.NewJsonResponderOrPanic(200, FooBar{}))
+
+	var respBody TokenResponse
+	_, err = http.NewClient().Get(http.URLString("https://example.com/json")).
+		Send(context.Background(), http.FormStruct(&respBody))
+	assert.EqualError(t, err, "invalid Content-Type header, expected 'application/x-www-form-urlencoded', got application/json")
+}
@@ -6,7 +6,6 @@ require (
 	github.com/go-test/deep v1.0.7
 	github.com/jarcoal/httpmock v1.0.8
 	github.com/kr/pretty v0.1.0 // indirect
-	github.com/mitchellh/copystructure v1.2.0
 	github.com/stretchr/testify v1.7.0
 	gopkg.in/check.v1 v1.0.0-20180628173108-788fd7840127 // indirect
 )
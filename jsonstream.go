@@ -0,0 +1,120 @@
+package http
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+)
+
+// isNDJSONContentType reports whether the content type is for newline delimited JSON
+func isNDJSONContentType(ct string) bool {
+	switch mediaType(ct) {
+	case "application/x-ndjson", "application/ndjson", "application/jsonl", "application/x-jsonlines":
+		return true
+	}
+	return false
+}
+
+// streamJSON checks the response is JSON and reads its body without buffering, closing it afterwards
+func streamJSON(resp *Response, fn func(r io.Reader) error) error {
+	if ct := resp.Headers.Get("Content-Type"); ct != "" && !isJSONContentType(ct) && !isNDJSONContentType(ct) {
+		return fmt.Errorf("invalid Content-Type header, expected 'application/json', got %s", ct)
+	}
+	resp.body.unbuffered = true
+	defer resp.Close()
+
+	return fn(resp.body)
+}
+
+type JSONStreamOption struct {
+	fn func(*json.Decoder) error
+}
+
+// JSONStream is an option to decode a JSON response body incrementally.
+// The body is not kept in memory, so the response cannot be Reset afterwards.
+// The body is closed once fn returns
+func JSONStream(fn func(dec *json.Decoder) error) JSONStreamOption {
+	return JSONStreamOption{fn}
+}
+
+func (j JSONStreamOption) ProcessResponse(resp *Response) error {
+	return streamJSON(resp, func(r io.Reader) error {
+		return j.fn(json.NewDecoder(r))
+	})
+}
+
+type EachJSONOption struct {
+	item interface{}
+	fn   func() error
+}
+
+// EachJSON is an option to iterate over the records of a JSON response body without decoding it all at once.
+// The body can either be a JSON array, or a stream of JSON values such as newline delimited JSON.
+// Each record is decoded into item, a pointer, before fn is called.
+// Iteration stops early if fn returns an error, which is then returned from Send.
+// The body is not kept in memory, so the response cannot be Reset afterwards
+func EachJSON(item interface{}, fn func() error) EachJSONOption {
+	return EachJSONOption{item, fn}
+}
+
+func (e EachJSONOption) ProcessResponse(resp *Response) error {
+	return streamJSON(resp, func(r io.Reader) error {
+		br := bufio.NewReader(r)
+		array, err := startsWithArray(br)
+		if err != nil {
+			return err
+		}
+		return e.each(json.NewDecoder(br), array)
+	})
+}
+
+func (e EachJSONOption) each(dec *json.Decoder, array bool) error {
+	if array {
+		if _, err := dec.Token(); err != nil {
+			return err
+		}
+	}
+
+	for dec.More() {
+		if err := e.decode(dec); err != nil {
+			return err
+		}
+		if err := e.fn(); err != nil {
+			return err
+		}
+	}
+
+	if array {
+		if _, err := dec.Token(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decode resets the item before decoding the next record into it,
+// so that fields missing from the record don't keep their previous value
+func (e EachJSONOption) decode(dec *json.Decoder) error {
+	if v := reflect.ValueOf(e.item); v.Kind() == reflect.Ptr && !v.IsNil() {
+		v.Elem().Set(reflect.Zero(v.Elem().Type()))
+	}
+	return dec.Decode(e.item)
+}
+
+// startsWithArray reports whether the first JSON value in the reader is an array, without consuming it
+func startsWithArray(br *bufio.Reader) (bool, error) {
+	for {
+		b, err := br.ReadByte()
+		if err == io.EOF {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		if b != ' ' && b != '\t' && b != '\r' && b != '\n' {
+			return b == '[', br.UnreadByte()
+		}
+	}
+}
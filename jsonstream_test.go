@@ -0,0 +1,110 @@
+package http_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	stdhttp "net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/conradludgate/go-http"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func recordServer(n int) *httptest.Server {
+	return httptest.NewServer(stdhttp.HandlerFunc(func(w stdhttp.ResponseWriter, r *stdhttp.Request) {
+		if r.URL.Path == "/ndjson" {
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			for i := 0; i < n; i++ {
+				fmt.Fprintf(w, "{\"Foo\":\"%d\",\"Bar\":%d}\n", i, i)
+			}
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, "\n [")
+		for i := 0; i < n; i++ {
+			if i > 0 {
+				fmt.Fprint(w, ",")
+			}
+			if i%2 == 0 {
+				fmt.Fprintf(w, "{\"Foo\":\"%d\",\"Bar\":%d}", i, i)
+			} else {
+				fmt.Fprintf(w, "{\"Bar\":%d}", i)
+			}
+		}
+		fmt.Fprint(w, "]")
+	}))
+}
+
+func TestEachJSON(t *testing.T) {
+	server := recordServer(1000)
+	defer server.Close()
+
+	client := http.NewClient(http.URLString(server.URL))
+	ctx := context.Background()
+
+	for _, path := range []string{"array", "ndjson"} {
+		t.Run(path, func(t *testing.T) {
+			var item FooBar
+			var count int
+			_, err := client.Get(http.Path(path)).Send(ctx, http.EachJSON(&item, func() error {
+				assert.Equal(t, count, item.Bar)
+				if path == "ndjson" || count%2 == 0 {
+					assert.Equal(t, fmt.Sprint(count), item.Foo)
+				} else {
+					assert.Empty(t, item.Foo)
+				}
+				count++
+				return nil
+			}))
+			require.NoError(t, err)
+			assert.Equal(t, 1000, count)
+		})
+	}
+}
+
+func TestEachJSON_StopEarly(t *testing.T) {
+	server := recordServer(100000)
+	defer server.Close()
+
+	client := http.NewClient(http.URLString(server.URL))
+	errStop := errors.New("stop")
+
+	var item FooBar
+	_, err := client.Get(http.Path("array")).Send(context.Background(), http.EachJSON(&item, func() error {
+		if item.Bar == 10 {
+			return errStop
+		}
+		return nil
+	}))
+	assert.Equal(t, errStop, err)
+	assert.Equal(t, 10, item.Bar)
+}
+
+func TestJSONStream(t *testing.T) {
+	server := recordServer(3)
+	defer server.Close()
+
+	client := http.NewClient(http.URLString(server.URL))
+
+	var items []FooBar
+	_, err := client.Get(http.Path("array")).Send(context.Background(), http.JSONStream(func(dec *json.Decoder) error {
+		if _, err := dec.Token(); err != nil {
+			return err
+		}
+		for dec.More() {
+			var item FooBar
+			if err := dec.Decode(&item); err != nil {
+				return err
+			}
+			items = append(items, item)
+		}
+		return nil
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, []FooBar{{"0", 0}, {"", 1}, {"2", 2}}, items)
+}
@@ -0,0 +1,254 @@
+package http
+
+import (
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/textproto"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Part is a single part of a multipart/form-data request body
+type Part struct {
+	Header textproto.MIMEHeader
+	Body   io.Reader
+	// Size is the length of Body in bytes. If it is 0, the size is detected for in-memory readers and files
+	Size int64
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// FieldPart creates a form field part with the given value
+func FieldPart(name, value string) Part {
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"`, quoteEscaper.Replace(name)))
+	return Part{
+		Header: header,
+		Body:   strings.NewReader(value),
+		Size:   int64(len(value)),
+	}
+}
+
+// FilePart creates a file part, streaming its content from r.
+// The size of the part is detected for in-memory readers and files,
+// otherwise it can be provided using WithSize
+func FilePart(name, filename string, r io.Reader) Part {
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`,
+		quoteEscaper.Replace(name), quoteEscaper.Replace(filename)))
+	header.Set("Content-Type", "application/octet-stream")
+	return Part{
+		Header: header,
+		Body:   r,
+	}
+}
+
+// readerSize returns the number of bytes left in r, or -1 if it cannot be known
+func readerSize(r io.Reader) int64 {
+	switch v := r.(type) {
+	case interface{ Len() int }:
+		return int64(v.Len())
+	case *os.File:
+		info, err := v.Stat()
+		if err != nil || !info.Mode().IsRegular() {
+			return -1
+		}
+		offset, err := v.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return -1
+		}
+		return info.Size() - offset
+	default:
+		return -1
+	}
+}
+
+// WithHeader returns a copy of the part with the header set, such as a Content-Type
+func (p Part) WithHeader(key, value string) Part {
+	header := make(textproto.MIMEHeader, len(p.Header)+1)
+	for k, vs := range p.Header {
+		header[k] = append([]string(nil), vs...)
+	}
+	header.Set(key, value)
+	p.Header = header
+	return p
+}
+
+// WithSize returns a copy of the part with a known size
+func (p Part) WithSize(size int64) Part {
+	p.Size = size
+	return p
+}
+
+type MultipartOption struct {
+	parts []Part
+}
+
+// Multipart is an option to add a multipart/form-data body to a request.
+// The body is streamed from the parts as it is sent, so parts are never fully buffered in memory.
+// If the sizes of all parts are known, the Content-Length of the request is set.
+// The request can only be re-sent without buffering if all the part bodies are io.Seekers
+func Multipart(parts ...Part) MultipartOption {
+	return MultipartOption{parts}
+}
+
+func (m MultipartOption) ModifyRequest(r *Request) error {
+	boundary := multipart.NewWriter(io.Discard).Boundary()
+
+	length, err := multipartLength(boundary, m.parts)
+	if err != nil {
+		return fmt.Errorf("cannot encode request body: %w", err)
+	}
+
+	body := &multipartBody{boundary: boundary, parts: m.parts}
+	r.GetBody = nil
+	r.Body = &lazyBody{open: body.open}
+	if body.seekable() {
+		// the first body rewinds the parts too, in case GetBody was read before the request is sent
+		r.GetBody = body.reopen
+		r.Body = &lazyBody{open: body.reopen}
+	}
+	r.ContentLength = length
+
+	return SetHeader("Content-Type", "multipart/form-data; boundary="+boundary).ModifyRequest(r)
+}
+
+type countWriter int64
+
+func (c *countWriter) Write(p []byte) (int, error) {
+	*c += countWriter(len(p))
+	return len(p), nil
+}
+
+// multipartLength computes the length of the encoded body, or 0 if any part has an unknown size
+func multipartLength(boundary string, parts []Part) (int64, error) {
+	var count countWriter
+	w := multipart.NewWriter(&count)
+	if err := w.SetBoundary(boundary); err != nil {
+		return 0, err
+	}
+	var size int64
+	for _, p := range parts {
+		partSize := p.Size
+		if partSize <= 0 {
+			partSize = readerSize(p.Body)
+		}
+		if partSize < 0 {
+			return 0, nil
+		}
+		size += partSize
+		if _, err := w.CreatePart(p.Header); err != nil {
+			return 0, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return 0, err
+	}
+	return int64(count) + size, nil
+}
+
+// multipartBody writes the parts into a pipe as the request body is read
+type multipartBody struct {
+	boundary string
+	parts    []Part
+	offsets  []int64
+
+	mu   sync.Mutex
+	pr   *io.PipeReader
+	done chan struct{}
+}
+
+// seekable records the current offsets of the parts, reporting whether they can all be rewound
+func (b *multipartBody) seekable() bool {
+	offsets := make([]int64, len(b.parts))
+	for i, p := range b.parts {
+		s, ok := p.Body.(io.Seeker)
+		if !ok {
+			return false
+		}
+		offset, err := s.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return false
+		}
+		offsets[i] = offset
+	}
+	b.offsets = offsets
+	return true
+}
+
+func (b *multipartBody) open() (io.ReadCloser, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	pr, pw := io.Pipe()
+	done := make(chan struct{})
+	b.pr, b.done = pr, done
+
+	go func() {
+		defer close(done)
+		pw.CloseWithError(b.write(pw))
+	}()
+
+	return pr, nil
+}
+
+// reopen stops any previous write of the body and rewinds the parts before writing them again
+func (b *multipartBody) reopen() (io.ReadCloser, error) {
+	b.mu.Lock()
+	if b.pr != nil {
+		_ = b.pr.Close()
+		<-b.done
+	}
+	b.mu.Unlock()
+
+	for i, p := range b.parts {
+		if _, err := p.Body.(io.Seeker).Seek(b.offsets[i], io.SeekStart); err != nil {
+			return nil, err
+		}
+	}
+	return b.open()
+}
+
+func (b *multipartBody) write(pw io.Writer) error {
+	w := multipart.NewWriter(pw)
+	if err := w.SetBoundary(b.boundary); err != nil {
+		return err
+	}
+	for _, p := range b.parts {
+		part, err := w.CreatePart(p.Header)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(part, p.Body); err != nil {
+			return err
+		}
+	}
+	return w.Close()
+}
+
+// lazyBody delays opening a body until it is first read,
+// so that nothing is started for requests that are never sent
+type lazyBody struct {
+	open func() (io.ReadCloser, error)
+	rc   io.ReadCloser
+}
+
+func (l *lazyBody) Read(p []byte) (int, error) {
+	if l.rc == nil {
+		rc, err := l.open()
+		if err != nil {
+			return 0, err
+		}
+		l.rc = rc
+	}
+	return l.rc.Read(p)
+}
+
+func (l *lazyBody) Close() error {
+	if l.rc == nil {
+		return nil
+	}
+	return l.rc.Close()
+}
@@ -0,0 +1,159 @@
+package http_test
+
+import (
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	stdhttp "net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/conradludgate/go-http"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// multipartServer responds with a summary of the parts in each request
+func multipartServer(t *testing.T, contentLength *int64) *httptest.Server {
+	return httptest.NewServer(stdhttp.HandlerFunc(func(w stdhttp.ResponseWriter, r *stdhttp.Request) {
+		atomic.StoreInt64(contentLength, r.ContentLength)
+
+		mediaType, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		require.NoError(t, err)
+		assert.Equal(t, "multipart/form-data", mediaType)
+
+		mr := multipart.NewReader(r.Body, params["boundary"])
+		for {
+			part, err := mr.NextPart()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			b, err := io.ReadAll(part)
+			require.NoError(t, err)
+			_, _ = io.WriteString(w, part.FormName()+"|"+part.FileName()+"|"+part.Header.Get("Content-Type")+"|"+string(b)+"\n")
+		}
+	}))
+}
+
+func TestMultipart(t *testing.T) {
+	var contentLength int64
+	server := multipartServer(t, &contentLength)
+	defer server.Close()
+
+	client := http.NewClient(http.URLString(server.URL))
+	ctx := context.Background()
+
+	resp, err := client.Post(http.Multipart(
+		http.FieldPart("title", "hello"),
+		http.FilePart("file", "hello.txt", strings.NewReader("hello world")).
+			WithHeader("Content-Type", "text/plain"),
+	)).Send(ctx)
+	require.NoError(t, err)
+
+	b, err := io.ReadAll(resp)
+	require.NoError(t, err)
+	assert.Equal(t, "title|||hello\nfile|hello.txt|text/plain|hello world\n", string(b))
+	assert.Greater(t, atomic.LoadInt64(&contentLength), int64(len("hello"+"hello world")))
+}
+
+func TestMultipart_UnknownSize(t *testing.T) {
+	var contentLength int64
+	server := multipartServer(t, &contentLength)
+	defer server.Close()
+
+	client := http.NewClient(http.URLString(server.URL))
+	ctx := context.Background()
+
+	pr, pw := io.Pipe()
+	go func() {
+		for i := 0; i < 3; i++ {
+			_, _ = io.WriteString(pw, "chunk")
+			time.Sleep(time.Millisecond)
+		}
+		_ = pw.Close()
+	}()
+
+	resp, err := client.Post(http.Multipart(
+		http.FilePart("file", "stream.bin", pr),
+	)).Send(ctx)
+	require.NoError(t, err)
+
+	b, err := io.ReadAll(resp)
+	require.NoError(t, err)
+	assert.Equal(t, "file|stream.bin|application/octet-stream|chunkchunkchunk\n", string(b))
+	assert.EqualValues(t, -1, atomic.LoadInt64(&contentLength))
+}
+
+func TestMultipart_PartLiteral(t *testing.T) {
+	var contentLength int64
+	server := multipartServer(t, &contentLength)
+	defer server.Close()
+
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", `form-data; name="title"`)
+
+	client := http.NewClient(http.URLString(server.URL))
+	resp, err := client.Post(http.Multipart(
+		http.Part{Header: header, Body: strings.NewReader("hello")},
+	)).Send(context.Background())
+	require.NoError(t, err)
+
+	b, err := io.ReadAll(resp)
+	require.NoError(t, err)
+	assert.Equal(t, "title|||hello\n", string(b))
+	assert.Greater(t, atomic.LoadInt64(&contentLength), int64(len("hello")))
+}
+
+// readGetBody is a request option reading the body from GetBody, like the signing options do
+type readGetBody struct{}
+
+func (readGetBody) ModifyRequest(r *http.Request) error {
+	body, err := r.GetBody()
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	_, err = io.Copy(io.Discard, body)
+	return err
+}
+
+func TestMultipart_GetBodyBeforeSend(t *testing.T) {
+	var contentLength int64
+	server := multipartServer(t, &contentLength)
+	defer server.Close()
+
+	client := http.NewClient(http.URLString(server.URL))
+	resp, err := client.Post(
+		http.Multipart(http.FieldPart("title", "hello")),
+		readGetBody{},
+	).Send(context.Background())
+	require.NoError(t, err)
+
+	b, err := io.ReadAll(resp)
+	require.NoError(t, err)
+	assert.Equal(t, "title|||hello\n", string(b))
+}
+
+func TestMultipart_Retry(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(stdhttp.HandlerFunc(func(w stdhttp.ResponseWriter, r *stdhttp.Request) {
+		require.NoError(t, r.ParseMultipartForm(1<<20))
+		assert.Equal(t, "hello", r.FormValue("title"))
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.WriteHeader(stdhttp.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	client := http.NewClient(http.URLString(server.URL), fastRetry(2))
+	resp, err := client.Post(http.Multipart(http.FieldPart("title", "hello"))).Send(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.EqualValues(t, 2, atomic.LoadInt32(&attempts))
+}
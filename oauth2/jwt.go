@@ -0,0 +1,115 @@
+package oauth2
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// JWTConfig configures the assertions signed for the JWT bearer grant
+type JWTConfig struct {
+	// Issuer and Subject identify the client, and the user it acts on behalf of
+	Issuer  string
+	Subject string
+	// Audience identifies the authorization server, usually its token URL
+	Audience string
+	// PrivateKey signs the assertions. RSA keys use RS256, ECDSA P-256 keys use ES256 and Ed25519 keys use EdDSA
+	PrivateKey crypto.Signer
+	// KeyID is sent in the kid header of the assertions, if set
+	KeyID string
+	// Lifetime is how long the assertions are valid for. Defaults to 1 hour
+	Lifetime time.Duration
+	// Claims are added to the claims of the assertions
+	Claims map[string]interface{}
+}
+
+// sign creates a signed JWT assertion, issued at now
+func (j JWTConfig) sign(now time.Time) (string, error) {
+	var alg string
+	var hash crypto.Hash
+	switch key := j.PrivateKey.Public().(type) {
+	case *rsa.PublicKey:
+		alg, hash = "RS256", crypto.SHA256
+	case *ecdsa.PublicKey:
+		if key.Curve.Params().BitSize != 256 {
+			return "", fmt.Errorf("unsupported ECDSA curve %s", key.Curve.Params().Name)
+		}
+		alg, hash = "ES256", crypto.SHA256
+	case ed25519.PublicKey:
+		alg = "EdDSA"
+	default:
+		return "", fmt.Errorf("unsupported key type %T", key)
+	}
+
+	header := map[string]string{"alg": alg, "typ": "JWT"}
+	if j.KeyID != "" {
+		header["kid"] = j.KeyID
+	}
+
+	lifetime := j.Lifetime
+	if lifetime <= 0 {
+		lifetime = time.Hour
+	}
+	jti := make([]byte, 16)
+	if _, err := rand.Read(jti); err != nil {
+		return "", err
+	}
+	claims := map[string]interface{}{}
+	for k, v := range j.Claims {
+		claims[k] = v
+	}
+	claims["iss"] = j.Issuer
+	claims["sub"] = j.Subject
+	claims["aud"] = j.Audience
+	claims["iat"] = now.Unix()
+	claims["exp"] = now.Add(lifetime).Unix()
+	claims["jti"] = hex.EncodeToString(jti)
+
+	h, err := json.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+	c, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	signingInput := base64.RawURLEncoding.EncodeToString(h) + "." + base64.RawURLEncoding.EncodeToString(c)
+
+	digest := []byte(signingInput)
+	if hash != 0 {
+		sum := sha256.Sum256(digest)
+		digest = sum[:]
+	}
+	sig, err := j.PrivateKey.Sign(rand.Reader, digest, hash)
+	if err != nil {
+		return "", err
+	}
+	if alg == "ES256" {
+		if sig, err = rawECDSASignature(sig); err != nil {
+			return "", err
+		}
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// rawECDSASignature converts an ASN.1 ECDSA P-256 signature to the fixed size r || s form used by JWS
+func rawECDSASignature(der []byte) ([]byte, error) {
+	var sig struct{ R, S *big.Int }
+	if _, err := asn1.Unmarshal(der, &sig); err != nil {
+		return nil, fmt.Errorf("invalid ECDSA signature: %w", err)
+	}
+	raw := make([]byte, 64)
+	sig.R.FillBytes(raw[:32])
+	sig.S.FillBytes(raw[32:])
+	return raw, nil
+}
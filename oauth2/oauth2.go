@@ -0,0 +1,155 @@
+// Package oauth2 fetches OAuth 2.0 access tokens (RFC 6749) using a go-http client,
+// so that token requests go through the same middlewares as any other request
+package oauth2
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/conradludgate/go-http"
+)
+
+// Config is the configuration of an OAuth 2.0 client
+type Config struct {
+	// TokenURL is the token endpoint of the authorization server
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	// CredentialsInBody sends the client credentials in the request body
+	// instead of using HTTP Basic authentication
+	CredentialsInBody bool
+	// Client sends the token requests. Defaults to a new client
+	Client *http.Client
+}
+
+// Error is an error response from the token endpoint (RFC 6749, 5.2).
+// It can be retrieved from the errors of requests using errors.As
+type Error struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+	URI         string `json:"error_uri,omitempty"`
+}
+
+func (e *Error) Error() string {
+	if e.Description == "" {
+		return "oauth2: " + e.Code
+	}
+	return fmt.Sprintf("oauth2: %s: %s", e.Code, e.Description)
+}
+
+type tokenResponse struct {
+	AccessToken  string    `json:"access_token"`
+	TokenType    string    `json:"token_type"`
+	ExpiresIn    expiresIn `json:"expires_in"`
+	RefreshToken string    `json:"refresh_token"`
+}
+
+// expiresIn is a number of seconds, which some servers send as a string
+type expiresIn int64
+
+func (e *expiresIn) UnmarshalJSON(b []byte) error {
+	s := strings.Trim(string(b), `"`)
+	if s == "" || s == "null" {
+		return nil
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid expires_in %s", b)
+	}
+	*e = expiresIn(n)
+	return nil
+}
+
+// token sends a token request with the grant parameters, returning the access token and the refresh token
+func (c Config) token(ctx context.Context, params url.Values) (*http.Token, string, error) {
+	if len(c.Scopes) > 0 {
+		params.Set("scope", strings.Join(c.Scopes, " "))
+	}
+	options := []http.RequestOption{http.URLString(c.TokenURL), http.SetHeader("Accept", "application/json")}
+	if c.CredentialsInBody {
+		params.Set("client_id", c.ClientID)
+		if c.ClientSecret != "" {
+			params.Set("client_secret", c.ClientSecret)
+		}
+	} else if c.ClientID != "" {
+		// RFC 6749, 2.3.1 form encodes the credentials before using them
+		options = append(options, http.BasicAuth(url.QueryEscape(c.ClientID), url.QueryEscape(c.ClientSecret)))
+	}
+	options = append(options, http.Form(params))
+
+	client := c.Client
+	if client == nil {
+		client = http.NewClient()
+	}
+
+	var resp tokenResponse
+	_, err := client.Post(options...).Send(ctx, http.ErrorJSON(&Error{}), http.JSON(&resp))
+	if err != nil {
+		return nil, "", fmt.Errorf("cannot fetch token: %w", err)
+	}
+	if resp.AccessToken == "" {
+		return nil, "", fmt.Errorf("cannot fetch token: response has no access_token")
+	}
+
+	token := &http.Token{AccessToken: resp.AccessToken, TokenType: resp.TokenType}
+	if strings.EqualFold(token.TokenType, "bearer") {
+		// servers often send it in lowercase, which some resource servers reject
+		token.TokenType = "Bearer"
+	}
+	if resp.ExpiresIn > 0 {
+		token.Expiry = time.Now().Add(time.Duration(resp.ExpiresIn) * time.Second)
+	}
+	return token, resp.RefreshToken, nil
+}
+
+// ClientCredentials is an option to authenticate requests with tokens from the client credentials grant (RFC 6749, 4.4)
+func ClientCredentials(config Config) http.TokenSourceOption {
+	return http.TokenSource(http.TokenFetcherFunc(func(ctx context.Context) (*http.Token, error) {
+		token, _, err := config.token(ctx, url.Values{"grant_type": {"client_credentials"}})
+		return token, err
+	}))
+}
+
+// RefreshToken is an option to authenticate requests with tokens from the refresh token grant (RFC 6749, 6).
+// If the server rotates the refresh token, the new one is used for the next refresh
+func RefreshToken(config Config, refreshToken string) http.TokenSourceOption {
+	var mu sync.Mutex
+	return http.TokenSource(http.TokenFetcherFunc(func(ctx context.Context) (*http.Token, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		token, refresh, err := config.token(ctx, url.Values{
+			"grant_type":    {"refresh_token"},
+			"refresh_token": {refreshToken},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if refresh != "" {
+			refreshToken = refresh
+		}
+		return token, nil
+	}))
+}
+
+// JWTBearer is an option to authenticate requests with tokens from the JWT bearer grant (RFC 7523, 2.1).
+// A new assertion is signed for every token request
+func JWTBearer(config Config, assertion JWTConfig) http.TokenSourceOption {
+	return http.TokenSource(http.TokenFetcherFunc(func(ctx context.Context) (*http.Token, error) {
+		jwt, err := assertion.sign(time.Now())
+		if err != nil {
+			return nil, fmt.Errorf("cannot sign assertion: %w", err)
+		}
+		token, _, err := config.token(ctx, url.Values{
+			"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+			"assertion":  {jwt},
+		})
+		return token, err
+	}))
+}
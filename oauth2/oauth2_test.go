@@ -0,0 +1,177 @@
+package oauth2_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	stdhttp "net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/conradludgate/go-http"
+	"github.com/conradludgate/go-http/oauth2"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tokenServer implements the token endpoint for the client "id" with the secret "secret"
+func tokenServer(t *testing.T, key *ecdsa.PublicKey) *httptest.Server {
+	var issued int32
+	return httptest.NewServer(stdhttp.HandlerFunc(func(w stdhttp.ResponseWriter, r *stdhttp.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fail := func(code string) {
+			w.WriteHeader(stdhttp.StatusBadRequest)
+			fmt.Fprintf(w, `{"error": %q, "error_description": "bad %s"}`, code, r.Form.Get("grant_type"))
+		}
+
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "tenant", r.Header.Get("X-Tenant"))
+		if id, secret, _ := r.BasicAuth(); id != "id" || secret != "secret" {
+			fail("invalid_client")
+			return
+		}
+
+		switch r.Form.Get("grant_type") {
+		case "client_credentials":
+			assert.Equal(t, "read write", r.Form.Get("scope"))
+		case "refresh_token":
+			rt := r.Form.Get("refresh_token")
+			if rt != fmt.Sprintf("rt%d", atomic.LoadInt32(&issued)) {
+				fail("invalid_grant")
+				return
+			}
+		case "urn:ietf:params:oauth:grant-type:jwt-bearer":
+			parts := strings.Split(r.Form.Get("assertion"), ".")
+			require.Len(t, parts, 3)
+			sig, _ := base64.RawURLEncoding.DecodeString(parts[2])
+			digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+			if len(sig) != 64 || !ecdsa.Verify(key, digest[:], new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])) {
+				fail("invalid_grant")
+				return
+			}
+			claims, _ := base64.RawURLEncoding.DecodeString(parts[1])
+			var c map[string]interface{}
+			require.NoError(t, json.Unmarshal(claims, &c))
+			assert.Equal(t, "id", c["iss"])
+			assert.Equal(t, "user", c["sub"])
+		default:
+			fail("unsupported_grant_type")
+			return
+		}
+
+		n := atomic.AddInt32(&issued, 1)
+		fmt.Fprintf(w, `{"access_token": "a%d", "token_type": "bearer", "expires_in": "3600", "refresh_token": "rt%d"}`, n, n)
+	}))
+}
+
+// mockResource mocks a resource server at resourceURL which responds with the Authorization header of the request.
+// Other requests, such as to the token server, are sent as usual
+func mockResource() {
+	httpmock.Activate()
+	httpmock.RegisterNoResponder(httpmock.InitialTransport.RoundTrip)
+	httpmock.RegisterResponder("GET", resourceURL, func(req *stdhttp.Request) (*stdhttp.Response, error) {
+		resp := httpmock.NewStringResponse(stdhttp.StatusOK, "")
+		resp.Header.Set("Authorization", req.Header.Get("Authorization"))
+		return resp, nil
+	})
+}
+
+const resourceURL = "https://api.example.com/resource"
+
+// authorization sends a request with the client, returning the Authorization header it was sent with
+func authorization(t *testing.T, client *http.Client) string {
+	t.Helper()
+	resp, err := client.Get().Send(context.Background())
+	require.NoError(t, err)
+	return resp.Headers.Get("Authorization")
+}
+
+func config(server *httptest.Server) oauth2.Config {
+	return oauth2.Config{
+		TokenURL:     server.URL,
+		ClientID:     "id",
+		ClientSecret: "secret",
+		Client:       http.NewClient(http.SetHeader("X-Tenant", "tenant")),
+	}
+}
+
+func TestClientCredentials(t *testing.T) {
+	tokens := tokenServer(t, nil)
+	defer tokens.Close()
+	mockResource()
+	defer httpmock.DeactivateAndReset()
+
+	cfg := config(tokens)
+	cfg.Scopes = []string{"read", "write"}
+	client := http.NewClient(http.URLString(resourceURL), oauth2.ClientCredentials(cfg))
+
+	assert.Equal(t, "Bearer a1", authorization(t, client))
+	// the token is cached
+	assert.Equal(t, "Bearer a1", authorization(t, client))
+}
+
+func TestRefreshToken(t *testing.T) {
+	tokens := tokenServer(t, nil)
+	defer tokens.Close()
+	mockResource()
+	defer httpmock.DeactivateAndReset()
+
+	client := http.NewClient(http.URLString(resourceURL), oauth2.RefreshToken(config(tokens), "rt0"))
+	assert.Equal(t, "Bearer a1", authorization(t, client))
+
+	// an invalid refresh token fails with the error from the server
+	client = http.NewClient(http.URLString(resourceURL), oauth2.RefreshToken(config(tokens), "rt0"))
+	_, err := client.Get().Send(context.Background())
+	var oauthErr *oauth2.Error
+	require.True(t, errors.As(err, &oauthErr))
+	assert.Equal(t, "invalid_grant", oauthErr.Code)
+	assert.Equal(t, "oauth2: invalid_grant: bad refresh_token", oauthErr.Error())
+}
+
+func TestJWTBearer(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tokens := tokenServer(t, &key.PublicKey)
+	defer tokens.Close()
+	mockResource()
+	defer httpmock.DeactivateAndReset()
+
+	client := http.NewClient(http.URLString(resourceURL), oauth2.JWTBearer(config(tokens), oauth2.JWTConfig{
+		Issuer:     "id",
+		Subject:    "user",
+		Audience:   tokens.URL,
+		PrivateKey: key,
+	}))
+	assert.Equal(t, "Bearer a1", authorization(t, client))
+}
+
+func TestInvalidClient(t *testing.T) {
+	tokens := tokenServer(t, nil)
+	defer tokens.Close()
+	mockResource()
+	defer httpmock.DeactivateAndReset()
+
+	cfg := config(tokens)
+	cfg.ClientSecret = "wrong"
+	cfg.CredentialsInBody = true
+	client := http.NewClient(http.URLString(resourceURL), oauth2.ClientCredentials(cfg))
+
+	_, err := client.Get().Send(context.Background())
+	var statusErr *http.StatusError
+	require.True(t, errors.As(err, &statusErr))
+	assert.Equal(t, http.StatusBadRequest, statusErr.StatusCode)
+	var oauthErr *oauth2.Error
+	require.True(t, errors.As(err, &oauthErr))
+	assert.Equal(t, "invalid_client", oauthErr.Code)
+}
@@ -0,0 +1,373 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// PageStrategy finds the next page of a paginated listing
+type PageStrategy interface {
+	// Next returns the options to apply to the first request to get the page after resp,
+	// or false if resp is the last page. resp is nil when asking for the first page.
+	// The response body can be read, it is reset afterwards
+	Next(resp *Response) ([]RequestOption, bool, error)
+}
+
+// Pager iterates over the pages of a listing, only requesting each page when Next is called
+//
+//	pager := client.Paginate(ctx, client.Get(http.Path("items")), http.LinkNext())
+//	for pager.Next() {
+//		page := pager.Page()
+//		...
+//	}
+//	if err := pager.Err(); err != nil {
+//		...
+//	}
+type Pager struct {
+	ctx      context.Context
+	client   *Client
+	template *Request
+	strategy PageStrategy
+	options  []ResponseOption
+
+	next []RequestOption
+	more bool
+	page *Response
+	err  error
+}
+
+// Paginate iterates over the pages of a listing, starting with the first request which must not have been sent yet.
+// The strategy provides the options to get each page from the first request,
+// and every page is processed with the response options provided
+func (c *Client) Paginate(ctx context.Context, first *Request, strategy PageStrategy, options ...ResponseOption) *Pager {
+	p := &Pager{
+		ctx:      ctx,
+		client:   c,
+		template: first,
+		strategy: strategy,
+		options:  options,
+	}
+	if err := first.Error(); err != nil {
+		p.err = fmt.Errorf("request error: %w", err)
+		return p
+	}
+	p.next, p.more, p.err = strategy.Next(nil)
+	return p
+}
+
+// Next requests the next page, returning false once there are no more pages or if an error occurred
+func (p *Pager) Next() bool {
+	if p.err != nil || !p.more {
+		return false
+	}
+	if p.err = p.ctx.Err(); p.err != nil {
+		return false
+	}
+
+	req, err := p.template.clone()
+	if err != nil {
+		p.err = err
+		return false
+	}
+	req.Client = p.client
+	if p.err = req.applyOptions(p.next...); p.err != nil {
+		return false
+	}
+
+	p.more = false
+	options := append([]ResponseOption{pageOption{p}}, p.options...)
+	p.page, p.err = req.Send(p.ctx, options...)
+	return p.err == nil
+}
+
+// Page returns the current page
+func (p *Pager) Page() *Response {
+	return p.page
+}
+
+// Err returns the error that stopped the iteration, if any
+func (p *Pager) Err() error {
+	return p.err
+}
+
+// EachItem iterates over the items of every remaining page, decoding each into item before calling fn.
+// field is the JSON field holding the array of items in each page, using dots for nested fields,
+// or "" if the pages are JSON arrays. Iteration stops early if fn returns an error, which is then returned
+func (p *Pager) EachItem(field string, item interface{}, fn func() error) error {
+	var items []json.RawMessage
+	p.options = append(p.options, itemsOption{field, &items})
+
+	for p.Next() {
+		for _, raw := range items {
+			if v := reflect.ValueOf(item); v.Kind() == reflect.Ptr && !v.IsNil() {
+				v.Elem().Set(reflect.Zero(v.Elem().Type()))
+			}
+			if err := json.Unmarshal(raw, item); err != nil {
+				return err
+			}
+			if err := fn(); err != nil {
+				return err
+			}
+		}
+	}
+	return p.Err()
+}
+
+// pageOption asks the strategy for the next page before the other options process the page
+type pageOption struct {
+	p *Pager
+}
+
+func (o pageOption) ProcessResponse(resp *Response) error {
+	next, more, err := o.p.strategy.Next(resp)
+	if err != nil {
+		return err
+	}
+	o.p.next, o.p.more = next, more
+	return resp.Reset()
+}
+
+type itemsOption struct {
+	field string
+	items *[]json.RawMessage
+}
+
+func (o itemsOption) ProcessResponse(resp *Response) error {
+	items, err := jsonItems(resp, o.field)
+	*o.items = items
+	return err
+}
+
+// clone copies a request that has not been sent yet, so that it can be sent many times
+func (r *Request) clone() (*Request, error) {
+	r1 := *r
+	if r.URL != nil {
+		u := *r.URL
+		r1.URL = &u
+	}
+	r1.Headers = r.Headers.Clone()
+	if r.Body != nil {
+		if r.GetBody == nil {
+			return nil, fmt.Errorf("cannot send request body more than once without GetBody")
+		}
+		body, err := r.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		r1.Body = body
+	}
+	return &r1, nil
+}
+
+// jsonField decodes the value of the dotted field from the JSON body of the response.
+// It returns nil if the field is missing
+func jsonField(resp *Response, field string) (json.RawMessage, error) {
+	var raw json.RawMessage
+	if err := json.NewDecoder(resp).Decode(&raw); err != nil {
+		if err == io.EOF {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("cannot decode page: %w", err)
+	}
+	if field == "" {
+		return raw, nil
+	}
+	for _, key := range strings.Split(field, ".") {
+		var obj map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &obj); err != nil {
+			return nil, fmt.Errorf("cannot decode page field %s: %w", field, err)
+		}
+		if raw = obj[key]; raw == nil {
+			return nil, nil
+		}
+	}
+	return raw, nil
+}
+
+// jsonItems decodes the array of items in the dotted field of the response
+func jsonItems(resp *Response, field string) ([]json.RawMessage, error) {
+	raw, err := jsonField(resp, field)
+	if err != nil || raw == nil {
+		return nil, err
+	}
+	var items []json.RawMessage
+	if err := json.Unmarshal(raw, &items); err != nil {
+		return nil, fmt.Errorf("cannot decode page items: %w", err)
+	}
+	return items, nil
+}
+
+type LinkStrategy struct{}
+
+// LinkNext is a PageStrategy following the rel="next" link from the Link header (RFC 8288) of each page
+func LinkNext() LinkStrategy {
+	return LinkStrategy{}
+}
+
+func (LinkStrategy) Next(resp *Response) ([]RequestOption, bool, error) {
+	if resp == nil {
+		return nil, true, nil
+	}
+	link := findLink(resp.Headers.Values("Link"), "next")
+	if link == "" {
+		return nil, false, nil
+	}
+	next, err := resp.Request.URL.Parse(link)
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid next link %q: %w", link, err)
+	}
+	if next.String() == resp.Request.URL.String() {
+		return nil, false, nil
+	}
+	return []RequestOption{URL(next)}, true, nil
+}
+
+// findLink returns the target of the first link with the relation type in the Link headers
+func findLink(headers []string, rel string) string {
+	for _, header := range headers {
+		for header != "" {
+			start := strings.IndexByte(header, '<')
+			end := strings.IndexByte(header, '>')
+			if start < 0 || end < start {
+				break
+			}
+			target := header[start+1 : end]
+			header = header[end+1:]
+
+			// parameters run until the next comma outside of quotes
+			params, rest := header, ""
+			quoted := false
+			for i, c := range header {
+				if c == '"' {
+					quoted = !quoted
+				}
+				if c == ',' && !quoted {
+					params, rest = header[:i], header[i+1:]
+					break
+				}
+			}
+			header = rest
+
+			for _, param := range strings.Split(params, ";") {
+				eq := strings.IndexByte(param, '=')
+				if eq < 0 || !strings.EqualFold(strings.TrimSpace(param[:eq]), "rel") {
+					continue
+				}
+				for _, r := range strings.Fields(strings.Trim(strings.TrimSpace(param[eq+1:]), `"`)) {
+					if strings.EqualFold(r, rel) {
+						return target
+					}
+				}
+			}
+		}
+	}
+	return ""
+}
+
+type CursorStrategy struct {
+	field string
+	param string
+}
+
+// Cursor is a PageStrategy reading a cursor from the dotted field of each JSON page,
+// and sending it in the param query parameter to get the next page.
+// The listing ends when the cursor is missing, null or empty
+func Cursor(field, param string) CursorStrategy {
+	return CursorStrategy{field, param}
+}
+
+func (c CursorStrategy) Next(resp *Response) ([]RequestOption, bool, error) {
+	if resp == nil {
+		return nil, true, nil
+	}
+	raw, err := jsonField(resp, c.field)
+	if err != nil || raw == nil {
+		return nil, false, err
+	}
+
+	var cursor string
+	if bytes.HasPrefix(raw, []byte(`"`)) {
+		if err := json.Unmarshal(raw, &cursor); err != nil {
+			return nil, false, fmt.Errorf("cannot decode cursor: %w", err)
+		}
+	} else if string(raw) != "null" {
+		cursor = string(raw)
+	}
+	if cursor == "" || cursor == resp.Request.URL.Query().Get(c.param) {
+		return nil, false, nil
+	}
+	return []RequestOption{SetParam(c.param, cursor)}, true, nil
+}
+
+type OffsetStrategy struct {
+	offsetParam string
+	limitParam  string
+	limit       int
+	items       string
+}
+
+// OffsetLimit is a PageStrategy requesting pages of limit items using offset and limit query parameters.
+// The listing ends with the first page holding fewer than limit items
+func OffsetLimit(offsetParam, limitParam string, limit int) OffsetStrategy {
+	return OffsetStrategy{offsetParam: offsetParam, limitParam: limitParam, limit: limit}
+}
+
+// Items returns a copy of the strategy counting the items in the dotted field of each JSON page,
+// instead of expecting the pages to be JSON arrays
+func (o OffsetStrategy) Items(field string) OffsetStrategy {
+	o.items = field
+	return o
+}
+
+func (o OffsetStrategy) Next(resp *Response) ([]RequestOption, bool, error) {
+	offset := 0
+	if resp != nil {
+		items, err := jsonItems(resp, o.items)
+		if err != nil || len(items) < o.limit {
+			return nil, false, err
+		}
+		offset, _ = strconv.Atoi(resp.Request.URL.Query().Get(o.offsetParam))
+		offset += len(items)
+	}
+	return []RequestOption{
+		SetParam(o.offsetParam, strconv.Itoa(offset)),
+		SetParam(o.limitParam, strconv.Itoa(o.limit)),
+	}, true, nil
+}
+
+type PageNumberStrategy struct {
+	param string
+	items string
+}
+
+// PageNumber is a PageStrategy requesting numbered pages, starting at 1, using the param query parameter.
+// The listing ends with the first empty page
+func PageNumber(param string) PageNumberStrategy {
+	return PageNumberStrategy{param: param}
+}
+
+// Items returns a copy of the strategy counting the items in the dotted field of each JSON page,
+// instead of expecting the pages to be JSON arrays
+func (p PageNumberStrategy) Items(field string) PageNumberStrategy {
+	p.items = field
+	return p
+}
+
+func (p PageNumberStrategy) Next(resp *Response) ([]RequestOption, bool, error) {
+	page := 1
+	if resp != nil {
+		items, err := jsonItems(resp, p.items)
+		if err != nil || len(items) == 0 {
+			return nil, false, err
+		}
+		page, _ = strconv.Atoi(resp.Request.URL.Query().Get(p.param))
+		page++
+	}
+	return []RequestOption{SetParam(p.param, strconv.Itoa(page))}, true, nil
+}
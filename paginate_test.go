@@ -0,0 +1,132 @@
+package http_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	stdhttp "net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/conradludgate/go-http"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// listServer serves the items 0 to total-1 with every pagination style
+func listServer(total int) *httptest.Server {
+	slice := func(offset, limit int) []int {
+		items := []int{}
+		for i := offset; i < offset+limit && i < total; i++ {
+			items = append(items, i)
+		}
+		return items
+	}
+	writeJSON := func(w stdhttp.ResponseWriter, v interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(v)
+	}
+
+	mux := stdhttp.NewServeMux()
+	mux.HandleFunc("/link", func(w stdhttp.ResponseWriter, r *stdhttp.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("p"))
+		if (page+1)*3 < total {
+			w.Header().Add("Link", `<https://example.com/docs>; rel="help", </link?p=0>; rel="first"`)
+			w.Header().Add("Link", fmt.Sprintf(`</link?p=%d>; title="a, b"; rel="next last"`, page+1))
+		}
+		writeJSON(w, slice(page*3, 3))
+	})
+	mux.HandleFunc("/cursor", func(w stdhttp.ResponseWriter, r *stdhttp.Request) {
+		offset, _ := strconv.Atoi(r.URL.Query().Get("cursor"))
+		next := ""
+		if offset+3 < total {
+			next = strconv.Itoa(offset + 3)
+		}
+		writeJSON(w, map[string]interface{}{
+			"data": slice(offset, 3),
+			"meta": map[string]string{"next": next},
+		})
+	})
+	mux.HandleFunc("/offset", func(w stdhttp.ResponseWriter, r *stdhttp.Request) {
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		writeJSON(w, slice(offset, limit))
+	})
+	mux.HandleFunc("/pages", func(w stdhttp.ResponseWriter, r *stdhttp.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		writeJSON(w, map[string]interface{}{"items": slice((page-1)*3, 3)})
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestPaginate_EachItem(t *testing.T) {
+	server := listServer(7)
+	defer server.Close()
+
+	client := http.NewClient(http.URLString(server.URL))
+	ctx := context.Background()
+
+	tests := []struct {
+		path     string
+		strategy http.PageStrategy
+		field    string
+	}{
+		{"link", http.LinkNext(), ""},
+		{"cursor", http.Cursor("meta.next", "cursor"), "data"},
+		{"offset", http.OffsetLimit("offset", "limit", 3), ""},
+		{"pages", http.PageNumber("page").Items("items"), "items"},
+	}
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			pager := client.Paginate(ctx, client.Get(http.Path(test.path)), test.strategy)
+
+			var item int
+			var items []int
+			err := pager.EachItem(test.field, &item, func() error {
+				items = append(items, item)
+				return nil
+			})
+			require.NoError(t, err)
+			assert.Equal(t, []int{0, 1, 2, 3, 4, 5, 6}, items)
+		})
+	}
+}
+
+func TestPaginate_Pages(t *testing.T) {
+	server := listServer(6)
+	defer server.Close()
+
+	client := http.NewClient(http.URLString(server.URL))
+
+	var pages [][]int
+	pager := client.Paginate(context.Background(), client.Get(http.Path("offset")), http.OffsetLimit("offset", "limit", 3))
+	for pager.Next() {
+		var page []int
+		require.NoError(t, json.NewDecoder(pager.Page()).Decode(&page))
+		pages = append(pages, page)
+	}
+	require.NoError(t, pager.Err())
+
+	// the last page is empty as the server cannot tell there are no more items
+	assert.Equal(t, [][]int{{0, 1, 2}, {3, 4, 5}, {}}, pages)
+}
+
+func TestPaginate_Cancel(t *testing.T) {
+	server := listServer(100)
+	defer server.Close()
+
+	client := http.NewClient(http.URLString(server.URL))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	pages := 0
+	pager := client.Paginate(ctx, client.Get(http.Path("pages")), http.PageNumber("page").Items("items"), http.ExpectSuccess())
+	for pager.Next() {
+		if pages++; pages == 2 {
+			cancel()
+		}
+	}
+	assert.Equal(t, 2, pages)
+	assert.ErrorIs(t, pager.Err(), context.Canceled)
+}
@@ -0,0 +1,109 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// ProblemDetails is a RFC 7807 problem details object,
+// usually sent with the application/problem+json content type
+type ProblemDetails struct {
+	Type     string `json:"type,omitempty"`
+	Title    string `json:"title,omitempty"`
+	Status   int    `json:"status,omitempty"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+
+	// Extensions contains any additional members of the problem details object
+	Extensions map[string]interface{} `json:"-"`
+}
+
+func (p *ProblemDetails) Error() string {
+	title := p.Title
+	if title == "" {
+		title = p.Type
+	}
+	if p.Detail == "" {
+		return title
+	}
+	if title == "" {
+		return p.Detail
+	}
+	return fmt.Sprintf("%s: %s", title, p.Detail)
+}
+
+func (p *ProblemDetails) UnmarshalJSON(b []byte) error {
+	type problemDetails ProblemDetails
+	if err := json.Unmarshal(b, (*problemDetails)(p)); err != nil {
+		return err
+	}
+
+	members := make(map[string]interface{})
+	if err := json.Unmarshal(b, &members); err != nil {
+		return err
+	}
+	for _, key := range []string{"type", "title", "status", "detail", "instance"} {
+		delete(members, key)
+	}
+	p.Extensions = nil
+	if len(members) > 0 {
+		p.Extensions = members
+	}
+	return nil
+}
+
+type ErrorJSONOption struct {
+	v interface{}
+	// fresh decodes each response into a new value of the type v points to
+	fresh bool
+}
+
+// ErrorJSON is an option to decode the body of a non-2xx response into v.
+// The response then fails with a *StatusError holding v as its Value.
+// If v implements error, it can be retrieved from the returned error using errors.As.
+//
+// Given to a client, v is only used for its type: each response is decoded into a new value,
+// so that errors from different requests stay independent.
+//
+// Options are processed in order, so ErrorJSON must come before a JSON option
+// to prevent it from decoding error responses
+func ErrorJSON(v interface{}) ErrorJSONOption {
+	return ErrorJSONOption{v: v}
+}
+
+func (e ErrorJSONOption) ProcessResponse(resp *Response) error {
+	if resp.StatusCode.Type() == StatusTypeSuccess {
+		return nil
+	}
+
+	statusErr, err := newStatusError(resp)
+	if err != nil {
+		return fmt.Errorf("cannot read response body: %w", err)
+	}
+
+	ct := resp.Headers.Get("Content-Type")
+	if ct != "" && !isJSONContentType(ct) {
+		return statusErr
+	}
+	v := e.v
+	if t := reflect.TypeOf(e.v); e.fresh && t != nil && t.Kind() == reflect.Ptr {
+		v = reflect.New(t.Elem()).Interface()
+	}
+	err = json.NewDecoder(resp).Decode(v)
+	_ = resp.Reset()
+	if err != nil {
+		if ct == "" {
+			// without a content type, the body was not necessarily meant to be JSON
+			return statusErr
+		}
+		return fmt.Errorf("cannot decode error response (%s): %w", resp.StatusCode, err)
+	}
+
+	statusErr.Value = v
+	return statusErr
+}
+
+func (e ErrorJSONOption) ModifyClient(c *Client) {
+	PreResponseMiddlewares(ErrorJSONOption{v: e.v, fresh: true}).ModifyClient(c)
+}
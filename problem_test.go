@@ -0,0 +1,93 @@
+package http_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/conradludgate/go-http"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorJSON_ProblemDetails(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	problem := httpmock.NewStringResponse(403, `{
+		"type": "https://example.com/probs/out-of-credit",
+		"title": "You do not have enough credit.",
+		"detail": "Your current balance is 30, but that costs 50.",
+		"status": 403,
+		"balance": 30
+	}`)
+	problem.Header.Set("Content-Type", "application/problem+json")
+	httpmock.RegisterResponder("POST", "https://example.com/api/buy", httpmock.ResponderFromResponse(problem))
+
+	client := http.NewClient(http.URLString("https://example.com/api"))
+
+	var respBody FooBar
+	_, err := client.Post(http.Path("buy")).Send(context.Background(),
+		http.ErrorJSON(new(http.ProblemDetails)),
+		http.JSON(&respBody),
+	)
+	assert.EqualError(t, err, "unexpected response status: 403 Forbidden: You do not have enough credit.: Your current balance is 30, but that costs 50.")
+
+	var details *http.ProblemDetails
+	require.True(t, errors.As(err, &details))
+	assert.Equal(t, "https://example.com/probs/out-of-credit", details.Type)
+	assert.Equal(t, 403, details.Status)
+	assert.Equal(t, map[string]interface{}{"balance": float64(30)}, details.Extensions)
+
+	var statusErr *http.StatusError
+	require.True(t, errors.As(err, &statusErr))
+	assert.Equal(t, http.StatusForbidden, statusErr.StatusCode)
+}
+
+type APIError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *APIError) Error() string {
+	return e.Code + ": " + e.Message
+}
+
+func TestErrorJSON_Client(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "https://example.com/api/item",
+		httpmock.NewJsonResponderOrPanic(404, APIError{Code: "not_found", Message: "no such item"}))
+	httpmock.RegisterResponder("GET", "https://example.com/api/order",
+		httpmock.NewJsonResponderOrPanic(409, map[string]string{"code": "conflict"}))
+	httpmock.RegisterResponder("GET", "https://example.com/api/proxy",
+		httpmock.NewStringResponder(502, "bad gateway"))
+
+	apiErr := new(APIError)
+	client := http.NewClient(
+		http.URLString("https://example.com/api"),
+		http.ErrorJSON(apiErr),
+	)
+	ctx := context.Background()
+
+	_, err := client.Get(http.Path("item")).Send(ctx)
+	var target *APIError
+	require.True(t, errors.As(err, &target))
+	assert.Equal(t, "not_found", target.Code)
+
+	// each response is decoded into a new value
+	_, err2 := client.Get(http.Path("order")).Send(ctx)
+	var target2 *APIError
+	require.True(t, errors.As(err2, &target2))
+	assert.Equal(t, "conflict: ", target2.Error())
+	assert.Equal(t, "not_found: no such item", target.Error())
+	assert.EqualError(t, err, "unexpected response status: 404 Not Found: not_found: no such item")
+	assert.Equal(t, APIError{}, *apiErr)
+
+	// non-JSON error bodies are left in the status error
+	_, err = client.Get(http.Path("proxy")).Send(ctx)
+	assert.EqualError(t, err, "unexpected response status: 502 Bad Gateway: bad gateway")
+	assert.False(t, errors.As(err, &target))
+}
@@ -0,0 +1,206 @@
+package http
+
+import (
+	"context"
+	stdhttp "net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateLimiter decides when requests can be sent. Limiters must be safe for concurrent use
+type RateLimiter interface {
+	// Wait blocks until a request can be sent to host, or until ctx is done
+	Wait(ctx context.Context, host string) error
+	// Update adapts the limiter to a response received from host
+	Update(host string, resp *stdhttp.Response)
+}
+
+type RateLimitOption struct {
+	limiter RateLimiter
+}
+
+// RateLimit is an option to wait for the limiter before sending each request, including retries.
+// Waiting stops with the error of the request context if it is done first
+func RateLimit(limiter RateLimiter) RateLimitOption {
+	return RateLimitOption{limiter}
+}
+
+func (o RateLimitOption) ModifyClient(c *Client) {
+	TransportMiddlewares(func(next Doer) Doer {
+		return DoerFunc(func(req *stdhttp.Request) (*stdhttp.Response, error) {
+			if err := o.limiter.Wait(req.Context(), req.URL.Host); err != nil {
+				return nil, err
+			}
+			resp, err := next.Do(req)
+			if err == nil {
+				o.limiter.Update(req.URL.Host, resp)
+			}
+			return resp, err
+		})
+	}).ModifyClient(c)
+}
+
+// TokenBucket is a RateLimiter allowing bursts of requests, refilled at a steady rate.
+//
+// It also follows the rate limits sent by servers: once a response says that no requests remain,
+// using RateLimit-Remaining and RateLimit-Reset headers or their X-RateLimit-* equivalents,
+// or a 429 Too Many Requests response has a Retry-After header,
+// requests wait until the limit is reset
+type TokenBucket struct {
+	rate    float64
+	burst   int
+	perHost bool
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+// NewTokenBucket creates a TokenBucket sending up to rate requests per second on average,
+// and up to burst requests at once. If rate is zero, only the limits sent by servers apply
+func NewTokenBucket(rate float64, burst int) *TokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &TokenBucket{rate: rate, burst: burst, buckets: make(map[string]*bucket)}
+}
+
+// NewHostTokenBucket creates a TokenBucket like NewTokenBucket, with a separate bucket for each host
+func NewHostTokenBucket(rate float64, burst int) *TokenBucket {
+	t := NewTokenBucket(rate, burst)
+	t.perHost = true
+	return t
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	// blockedUntil is when the server said that requests can be sent again
+	blockedUntil time.Time
+}
+
+// bucket returns the bucket for the host. t.mu must be held
+func (t *TokenBucket) bucket(host string) *bucket {
+	if !t.perHost {
+		host = ""
+	}
+	b, ok := t.buckets[host]
+	if !ok {
+		b = &bucket{tokens: float64(t.burst), last: time.Now()}
+		t.buckets[host] = b
+	}
+	return b
+}
+
+func (t *TokenBucket) Wait(ctx context.Context, host string) error {
+	t.mu.Lock()
+	now := time.Now()
+	b := t.bucket(host)
+
+	var wait time.Duration
+	if b.blockedUntil.After(now) {
+		wait = b.blockedUntil.Sub(now)
+	}
+	reserved := false
+	if t.rate > 0 {
+		// refill the bucket, then reserve a token, waiting until it is available if the bucket is empty
+		b.tokens += now.Sub(b.last).Seconds() * t.rate
+		if b.tokens > float64(t.burst) {
+			b.tokens = float64(t.burst)
+		}
+		b.last = now
+		b.tokens--
+		reserved = true
+		if b.tokens < 0 {
+			if d := time.Duration(-b.tokens / t.rate * float64(time.Second)); d > wait {
+				wait = d
+			}
+		}
+	}
+	t.mu.Unlock()
+
+	if wait <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		if reserved {
+			// give the token back for other requests
+			t.mu.Lock()
+			b.tokens++
+			t.mu.Unlock()
+		}
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func (t *TokenBucket) Update(host string, resp *stdhttp.Response) {
+	now := time.Now()
+	var until time.Time
+	remaining, reset, ok := parseRateLimit(resp.Header)
+	if ok && remaining == 0 {
+		until = now.Add(reset)
+	}
+	if resp.StatusCode == stdhttp.StatusTooManyRequests {
+		if d, ok := retryAfter(resp); ok {
+			until = now.Add(d)
+		}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	b := t.bucket(host)
+	if until.After(b.blockedUntil) {
+		b.blockedUntil = until
+	}
+	// don't let a burst use more requests than the server has left
+	if ok && remaining > 0 && b.tokens > float64(remaining) {
+		b.tokens = float64(remaining)
+	}
+}
+
+// parseRateLimit reads the number of remaining requests and the time until the limit resets from the
+// RateLimit header fields (draft-ietf-httpapi-ratelimit-headers) or the older X-RateLimit-* headers
+func parseRateLimit(h stdhttp.Header) (remaining int, reset time.Duration, ok bool) {
+	remainingValue := h.Get("RateLimit-Remaining")
+	resetValue := h.Get("RateLimit-Reset")
+	if remainingValue == "" {
+		remainingValue = h.Get("X-RateLimit-Remaining")
+		resetValue = h.Get("X-RateLimit-Reset")
+	}
+	if remainingValue == "" {
+		// newer drafts combine the fields into a single RateLimit header, as in "limit=100, remaining=0, reset=5"
+		for _, param := range strings.Split(h.Get("RateLimit"), ",") {
+			if eq := strings.IndexByte(param, '='); eq >= 0 {
+				switch strings.TrimSpace(param[:eq]) {
+				case "remaining", "r":
+					remainingValue = strings.TrimSpace(param[eq+1:])
+				case "reset", "t":
+					resetValue = strings.TrimSpace(param[eq+1:])
+				}
+			}
+		}
+	}
+
+	remaining, err := strconv.Atoi(remainingValue)
+	if err != nil || remaining < 0 {
+		return 0, 0, false
+	}
+	if seconds, err := strconv.ParseInt(resetValue, 10, 64); err == nil && seconds > 0 {
+		// X-RateLimit-Reset is often a unix timestamp rather than a number of seconds
+		if seconds > 1e9 {
+			reset = time.Until(time.Unix(seconds, 0))
+		} else {
+			reset = time.Duration(seconds) * time.Second
+		}
+	}
+	if reset < 0 {
+		reset = 0
+	}
+	return remaining, reset, true
+}
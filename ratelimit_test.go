@@ -0,0 +1,118 @@
+package http_test
+
+import (
+	"context"
+	stdhttp "net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/conradludgate/go-http"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func okServer() *httptest.Server {
+	return httptest.NewServer(stdhttp.HandlerFunc(func(w stdhttp.ResponseWriter, r *stdhttp.Request) {}))
+}
+
+func TestRateLimit_TokenBucket(t *testing.T) {
+	server := okServer()
+	defer server.Close()
+
+	client := http.NewClient(http.URLString(server.URL), http.RateLimit(http.NewTokenBucket(20, 2)))
+	ctx := context.Background()
+
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		_, err := client.Get().Send(ctx)
+		require.NoError(t, err)
+	}
+	// the first 2 requests use the burst, the next 2 wait 50ms each
+	assert.GreaterOrEqual(t, int64(time.Since(start)), int64(90*time.Millisecond))
+}
+
+func TestRateLimit_Context(t *testing.T) {
+	server := okServer()
+	defer server.Close()
+
+	client := http.NewClient(http.URLString(server.URL), http.RateLimit(http.NewTokenBucket(0.1, 1)))
+
+	_, err := client.Get().Send(context.Background())
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = client.Get().Send(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, int64(time.Since(start)), int64(time.Second))
+}
+
+func TestRateLimit_PerHost(t *testing.T) {
+	server1, server2 := okServer(), okServer()
+	defer server1.Close()
+	defer server2.Close()
+
+	client := http.NewClient(http.RateLimit(http.NewHostTokenBucket(0.1, 1)))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	_, err := client.Get(http.URLString(server1.URL)).Send(ctx)
+	require.NoError(t, err)
+	_, err = client.Get(http.URLString(server2.URL)).Send(ctx)
+	require.NoError(t, err)
+}
+
+func TestRateLimit_ServerHeaders(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(stdhttp.HandlerFunc(func(w stdhttp.ResponseWriter, r *stdhttp.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("X-RateLimit-Reset", "1")
+		}
+	}))
+	defer server.Close()
+
+	client := http.NewClient(http.URLString(server.URL), http.RateLimit(http.NewTokenBucket(0, 10)))
+	ctx := context.Background()
+
+	_, err := client.Get().Send(ctx)
+	require.NoError(t, err)
+
+	start := time.Now()
+	_, err = client.Get().Send(ctx)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, int64(time.Since(start)), int64(900*time.Millisecond))
+
+	// the limit has reset
+	start = time.Now()
+	_, err = client.Get().Send(ctx)
+	require.NoError(t, err)
+	assert.Less(t, int64(time.Since(start)), int64(500*time.Millisecond))
+}
+
+func TestRateLimit_TooManyRequests(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(stdhttp.HandlerFunc(func(w stdhttp.ResponseWriter, r *stdhttp.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.Header().Set("Retry-After", "5")
+			w.WriteHeader(stdhttp.StatusTooManyRequests)
+		}
+	}))
+	defer server.Close()
+
+	client := http.NewClient(http.URLString(server.URL), http.RateLimit(http.NewTokenBucket(0, 10)))
+
+	resp, err := client.Get().Send(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+
+	// the next request would have to wait for 5s
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = client.Get().Send(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.EqualValues(t, 1, atomic.LoadInt32(&requests))
+}
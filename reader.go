@@ -1,6 +1,7 @@
 package http
 
 import (
+	"errors"
 	"io"
 )
 
@@ -34,9 +35,18 @@ func (r *buffer) Reset() {
 	r.offset = 0
 }
 
+// ErrBodyNotBuffered is returned when resetting a response body that was not fully kept in memory
+var ErrBodyNotBuffered = errors.New("response body was not buffered")
+
 type responseReader struct {
 	reader io.ReadCloser
 	buffer *buffer
+	// unbuffered stops the data being read from being kept for Reset
+	unbuffered bool
+	// limit is the maximum number of bytes kept for Reset. Zero means there is no limit
+	limit int
+	// discarded is set once some data has been read without being kept
+	discarded bool
 }
 
 func newResponseReader(r io.ReadCloser) *responseReader {
@@ -48,19 +58,39 @@ func newResponseReader(r io.ReadCloser) *responseReader {
 
 func (r *responseReader) Read(p []byte) (n int, err error) {
 	if r.buffer.Len() == 0 && r.reader != nil {
-		n, err = io.TeeReader(r.reader, r.buffer).Read(p)
+		if r.unbuffered {
+			n, err = r.reader.Read(p)
+			r.discarded = r.discarded || n > 0
+		} else {
+			n, err = r.reader.Read(p)
+			if r.limit > 0 && len(r.buffer.buffer)+n > r.limit {
+				// over the limit, so stop buffering and release the memory
+				r.unbuffered, r.discarded = true, true
+				r.buffer = new(buffer)
+			} else {
+				_, _ = r.buffer.Write(p[:n])
+			}
+		}
 		if err != nil {
 			_ = r.reader.Close() // try close on error (most likely EOF). Ignoring read close errors...
 			r.reader = nil
 		}
 		return
 	} else {
-		return r.buffer.Read(p)
+		n, err = r.buffer.Read(p)
+		if err == io.EOF && r.reader != nil {
+			err = nil // the rest of the body is still to be read from the reader
+		}
+		return
 	}
 }
 
-func (r *responseReader) Reset() {
+func (r *responseReader) Reset() error {
+	if r.discarded {
+		return ErrBodyNotBuffered
+	}
 	r.buffer.Reset()
+	return nil
 }
 
 func (r *responseReader) Close() error {
@@ -69,3 +99,20 @@ func (r *responseReader) Close() error {
 	}
 	return nil
 }
+
+// maxDrainBytes is the most that is read from a response body when draining it
+const maxDrainBytes = 256 << 10
+
+// drain reads the rest of the body, up to max bytes, so that the connection can be re-used, then closes it.
+// The read position is kept, so any data drained into the buffer can still be read
+func (r *responseReader) drain(max int64) {
+	if r.reader == nil {
+		return
+	}
+	offset := r.buffer.offset
+	_, _ = io.Copy(io.Discard, io.LimitReader(r, max))
+	if offset <= len(r.buffer.buffer) {
+		r.buffer.offset = offset
+	}
+	_ = r.Close()
+}
@@ -25,7 +25,17 @@ type Request struct {
 	Body    io.ReadCloser
 	Headers stdhttp.Header
 
-	err error
+	// GetBody returns a fresh copy of Body, allowing the request to be re-sent.
+	// Set by the Body option for in-memory readers and by BodyFunc
+	GetBody func() (io.ReadCloser, error)
+	// ContentLength is the size of Body in bytes. Zero means unknown, unless Body is nil
+	ContentLength int64
+
+	retry  *RetryPolicy
+	tokens *tokenSource
+	// conditional is set by the precondition options
+	conditional bool
+	err         error
 }
 
 // Extract any errors out of the request that may have occured when building
@@ -40,7 +50,7 @@ func (r *Request) Send(ctx context.Context, options ...ResponseOption) (*Respons
 		return nil, fmt.Errorf("request error: %w", r.err)
 	}
 
-	if err := r.applyOptions(r.Client.PostRequestMiddlewares...); err != nil {
+	if err := r.applyOptions(ordered(r.Client.PostRequestMiddlewares)...); err != nil {
 		return nil, err
 	}
 
@@ -51,29 +61,45 @@ func (r *Request) Send(ctx context.Context, options ...ResponseOption) (*Respons
 	if r.Headers != nil {
 		req.Header = r.Headers
 	}
+	req.GetBody = r.GetBody
+	if r.Body != nil && r.ContentLength > 0 {
+		req.ContentLength = r.ContentLength
+	}
 
-	stdresp, err := r.Client.BaseClient().Do(req)
+	stdresp, err := r.do(req)
 	if err != nil {
 		return nil, err
 	}
 
 	resp := Response{
-		Headers:    stdresp.Header,
-		StatusCode: Status(stdresp.StatusCode),
-		body:       newResponseReader(stdresp.Body),
+		Request:       r,
+		Headers:       stdresp.Header,
+		StatusCode:    Status(stdresp.StatusCode),
+		ContentLength: stdresp.ContentLength,
+		body:          newResponseReader(stdresp.Body),
 	}
 
-	if err := resp.applyOptions(r.Client.PreResponseMiddlewares...); err != nil {
-		return &resp, err
+	err = resp.checkPrecondition()
+	if err == nil {
+		err = resp.process(r.Client, options)
 	}
-
-	if err := resp.applyOptions(options...); err != nil {
-		return &resp, err
+	if !resp.keepOpen {
+		resp.body.drain(maxDrainBytes)
 	}
+	return &resp, err
+}
 
-	if err := resp.applyOptions(r.Client.PostResponseMiddlewares...); err != nil {
-		return &resp, err
+// do sends the request through the transport middlewares, retrying according to the retry policy
+func (r *Request) do(req *stdhttp.Request) (*stdhttp.Response, error) {
+	doer := r.Client.doer()
+	if tokens := r.tokens; tokens != nil {
+		next := doer
+		doer = DoerFunc(func(req *stdhttp.Request) (*stdhttp.Response, error) {
+			return tokens.do(next, req)
+		})
 	}
-
-	return &resp, nil
+	if r.retry == nil || r.retry.MaxAttempts <= 1 {
+		return doer.Do(req)
+	}
+	return r.retry.do(doer, req)
 }
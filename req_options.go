@@ -2,12 +2,12 @@ package http
 
 import (
 	"bytes"
-	"encoding/json"
 	"fmt"
 	"io"
 	stdhttp "net/http"
 	"net/url"
 	"path"
+	"strings"
 )
 
 // RequestOption is the option type for requests
@@ -30,7 +30,7 @@ type JSONOption struct {
 
 func (j JSONOption) ModifyRequest(r *Request) error {
 	b := bytes.NewBuffer(nil)
-	if err := json.NewEncoder(b).Encode(j.v); err != nil {
+	if err := (JSONCodec{}).Encode(b, j.v); err != nil {
 		return fmt.Errorf("cannot encode request body: %w", err)
 	}
 	return r.applyOptions(Body(b), AddHeader("Content-Type", "application/json"))
@@ -54,17 +54,83 @@ func AddHeader(key string, values ...string) HeaderOption {
 
 func (h HeaderOption) ModifyRequest(r *Request) error {
 	if r.Headers == nil {
-		r.Headers = h.headers
-	} else {
-		for k, vs := range h.headers {
-			for _, v := range vs {
-				r.Headers.Add(k, v)
-			}
+		r.Headers = make(stdhttp.Header)
+	}
+	for k, vs := range h.headers {
+		for _, v := range vs {
+			r.Headers.Add(k, v)
 		}
 	}
 	return nil
 }
 
+type SetHeaderOption struct {
+	headers stdhttp.Header
+}
+
+// SetHeader is an option to set a HTTP header on a request, replacing any existing values
+func SetHeader(key string, values ...string) SetHeaderOption {
+	return SetHeaderOption{stdhttp.Header{
+		key: values,
+	}}
+}
+
+// Headers is an option to set many HTTP headers on a request, replacing any existing values
+func Headers(headers stdhttp.Header) SetHeaderOption {
+	return SetHeaderOption{headers.Clone()}
+}
+
+func (h SetHeaderOption) ModifyRequest(r *Request) error {
+	if r.Headers == nil {
+		r.Headers = make(stdhttp.Header)
+	}
+	for k, vs := range h.headers {
+		r.Headers.Del(k)
+		for _, v := range vs {
+			r.Headers.Add(k, v)
+		}
+	}
+	return nil
+}
+
+type DelHeaderOption struct {
+	keys []string
+}
+
+// DelHeader is an option to remove HTTP headers from a request
+func DelHeader(keys ...string) DelHeaderOption {
+	return DelHeaderOption{keys}
+}
+
+func (h DelHeaderOption) ModifyRequest(r *Request) error {
+	for _, k := range h.keys {
+		r.Headers.Del(k)
+	}
+	return nil
+}
+
+type HeaderFuncOption struct {
+	key string
+	fn  func(*Request) (string, error)
+}
+
+// HeaderFunc is an option to set a HTTP header on a request from a function.
+// The header is left unchanged if the function returns an empty value
+func HeaderFunc(key string, fn func(*Request) (string, error)) HeaderFuncOption {
+	return HeaderFuncOption{key, fn}
+}
+
+func (h HeaderFuncOption) ModifyRequest(r *Request) error {
+	v, err := h.fn(r)
+	if err != nil {
+		return fmt.Errorf("cannot compute header %s: %w", h.key, err)
+	}
+	if v == "" {
+		return nil
+	}
+	return SetHeader(h.key, v).ModifyRequest(r)
+}
+
 type BodyOption struct {
 	r io.Reader
 }
@@ -75,14 +141,65 @@ func (b BodyOption) ModifyRequest(r *Request) error {
 		rc = io.NopCloser(b.r)
 	}
 	r.Body = rc
+	r.GetBody = getBody(b.r)
+	r.ContentLength = 0
+	if l, ok := b.r.(interface{ Len() int }); ok {
+		r.ContentLength = int64(l.Len())
+	}
 	return nil
 }
 
+// getBody returns a function that replays the reader if it holds its content in memory
+func getBody(r io.Reader) func() (io.ReadCloser, error) {
+	switch v := r.(type) {
+	case *bytes.Buffer:
+		buf := v.Bytes()
+		return func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(buf)), nil
+		}
+	case *bytes.Reader:
+		snapshot := *v
+		return func() (io.ReadCloser, error) {
+			r := snapshot
+			return io.NopCloser(&r), nil
+		}
+	case *strings.Reader:
+		snapshot := *v
+		return func() (io.ReadCloser, error) {
+			r := snapshot
+			return io.NopCloser(&r), nil
+		}
+	default:
+		return nil
+	}
+}
+
 // Body is an option to add a body to a request
 func Body(r io.Reader) BodyOption {
 	return BodyOption{r}
 }
 
+type BodyFuncOption struct {
+	getBody func() (io.ReadCloser, error)
+}
+
+// BodyFunc is an option to add a body to a request from a factory function.
+// The function is called again each time the request needs to be re-sent
+func BodyFunc(getBody func() (io.ReadCloser, error)) BodyFuncOption {
+	return BodyFuncOption{getBody}
+}
+
+func (b BodyFuncOption) ModifyRequest(r *Request) error {
+	body, err := b.getBody()
+	if err != nil {
+		return fmt.Errorf("cannot create request body: %w", err)
+	}
+	r.Body = body
+	r.GetBody = b.getBody
+	r.ContentLength = 0
+	return nil
+}
+
 type PathOption struct {
 	segments []string
 }
@@ -117,6 +234,9 @@ func Param(key string, values ...string) ParamsOption {
 }
 
 func (q ParamsOption) ModifyRequest(r *Request) error {
+	if r.URL == nil {
+		return fmt.Errorf("cannot use params option because there's no url")
+	}
 	query := r.URL.Query()
 	for k, vs := range q.values {
 		for _, v := range vs {
@@ -127,6 +247,29 @@ func (q ParamsOption) ModifyRequest(r *Request) error {
 	return nil
 }
 
+type SetParamsOption struct {
+	values url.Values
+}
+
+// SetParam is an option to set a query parameter on a request, replacing any existing values
+func SetParam(key string, values ...string) SetParamsOption {
+	return SetParamsOption{values: url.Values{
+		key: values,
+	}}
+}
+
+func (q SetParamsOption) ModifyRequest(r *Request) error {
+	if r.URL == nil {
+		return fmt.Errorf("cannot use params option because there's no url")
+	}
+	query := r.URL.Query()
+	for k, vs := range q.values {
+		query[k] = append([]string(nil), vs...)
+	}
+	r.URL.RawQuery = query.Encode()
+	return nil
+}
+
 type URLOption struct {
 	url *url.URL
 }
@@ -137,7 +280,13 @@ func URL(url *url.URL) URLOption {
 }
 
 func (u URLOption) ModifyRequest(r *Request) error {
-	r.URL = u.url
+	if u.url == nil {
+		r.URL = nil
+		return nil
+	}
+	// copy the url so that other options can modify it without affecting the original
+	url := *u.url
+	r.URL = &url
 	return nil
 }
 
@@ -241,3 +241,54 @@ func TestQuery(t *testing.T) {
 	require.Nil(t, err)
 	assert.Equal(t, "correct", string(b))
 }
+
+func TestHeaders(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterNoResponder(Echo)
+
+	client := http.NewClient(
+		http.URLString("https://example.com/api"),
+		http.SetHeader("Accept", "application/json"),
+		http.SetHeader("Authorization", "Bearer client"),
+		http.HeaderFunc("X-Request-Id", func(r *http.Request) (string, error) {
+			if r.Method == http.Get {
+				return "", nil
+			}
+			return string(r.Method), nil
+		}),
+	)
+
+	headers := func(req *http.Request) string {
+		resp, _ := ReadBody(t, req)
+		return fmt.Sprintf("%v %v %v", resp.Headers.Values("Accept"), resp.Headers.Values("Authorization"), resp.Headers.Values("X-Request-Id"))
+	}
+
+	assert.Equal(t, "[application/json] [Bearer client] []", headers(client.Get()))
+	assert.Equal(t, "[text/plain] [] [POST]", headers(client.Post(
+		http.Path("foo"),
+		http.SetHeader("Accept", "text/plain"),
+		http.DelHeader("Authorization"),
+	)))
+	assert.Equal(t, "[application/json text/plain] [Bearer request] []", headers(client.Get(
+		http.AddHeader("Accept", "text/plain"),
+		http.Headers(stdhttp.Header{"Authorization": []string{"Bearer request"}}),
+	)))
+
+	client2 := client.With(http.SetHeader("Authorization", "Bearer client2"))
+	assert.Equal(t, "[application/json] [Bearer client2] []", headers(client2.Get()))
+	assert.Equal(t, "[application/json] [Bearer client] []", headers(client.Get()))
+
+	// request options override a client HeaderFunc
+	assert.Equal(t, "[application/json] [Bearer client] [abc]", headers(client.Put(http.SetHeader("X-Request-Id", "abc"))))
+	assert.Equal(t, "[application/json] [Bearer client] [PUT]", headers(client.Put()))
+}
+
+func TestHeaderFuncError(t *testing.T) {
+	client := http.NewClient()
+
+	err := client.Get(http.HeaderFunc("X-Foo", func(r *http.Request) (string, error) {
+		return "", fmt.Errorf("no foo")
+	})).Error()
+	assert.EqualError(t, err, "cannot compute header X-Foo: no foo")
+}
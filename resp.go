@@ -1,25 +1,33 @@
 package http
 
 import (
-	"encoding/json"
 	"fmt"
 	"io"
 	stdhttp "net/http"
-	"strings"
 )
 
 type Response struct {
+	// Request is the request that was sent to get this response
+	Request *Request
+
 	Headers    stdhttp.Header
 	StatusCode Status
-	body       *responseReader
+	// ContentLength is the size of the body in bytes, or -1 if it is unknown
+	ContentLength int64
+	body          *responseReader
+
+	keepOpen bool
 }
 
 func (r *Response) Read(p []byte) (n int, err error) {
 	return r.body.Read(p)
 }
 
-func (r *Response) Reset() {
-	r.body.Reset()
+// Reset rewinds the response body so that it can be read again.
+// It returns ErrBodyNotBuffered if some of the body was read without being kept in memory,
+// see Streaming and BufferUpTo
+func (r *Response) Reset() error {
+	return r.body.Reset()
 }
 
 func (r *Response) Close() error {
@@ -33,10 +41,26 @@ type ResponseOption interface {
 
 func (j JSONOption) ProcessResponse(resp *Response) error {
 	ct := resp.Headers.Get("Content-Type")
-	if ct != "" && !strings.HasPrefix(ct, "application/json") {
+	if ct != "" && !isJSONContentType(ct) {
 		return fmt.Errorf("invalid Content-Type header, expected 'application/json', got %s", ct)
 	}
-	return json.NewDecoder(resp.body).Decode(j.v)
+	return JSONCodec{}.Decode(resp.body, j.v)
+}
+
+// isJSONContentType reports whether the content type is application/json or has a +json suffix
+func isJSONContentType(ct string) bool {
+	return matchMediaType("application/json", mediaType(ct))
+}
+
+// process applies the client middlewares and the options to the response
+func (resp *Response) process(c *Client, options []ResponseOption) error {
+	if err := resp.applyOptions(c.PreResponseMiddlewares...); err != nil {
+		return err
+	}
+	if err := resp.applyOptions(options...); err != nil {
+		return err
+	}
+	return resp.applyOptions(c.PostResponseMiddlewares...)
 }
 
 func (resp *Response) applyOptions(options ...ResponseOption) error {
@@ -61,3 +85,24 @@ func (b BodyWriteOption) ProcessResponse(resp *Response) error {
 func WriteBodyTo(w io.Writer) BodyWriteOption {
 	return BodyWriteOption{w}
 }
+
+type KeepOpenOption struct{}
+
+// KeepOpen is an option to leave the response body open once Send returns,
+// so that it can be streamed by the caller. The caller must then Close the response.
+//
+// Without it, any of the body not read by the response options is drained into memory,
+// up to 256KiB, and the body is closed so the connection can be re-used.
+// A larger body is closed without being fully read, and reading it further fails
+func KeepOpen() KeepOpenOption {
+	return KeepOpenOption{}
+}
+
+func (KeepOpenOption) ProcessResponse(resp *Response) error {
+	resp.keepOpen = true
+	return nil
+}
+
+func (o KeepOpenOption) ModifyClient(c *Client) {
+	PreResponseMiddlewares(o).ModifyClient(c)
+}
@@ -153,3 +153,67 @@ func TestResponse_JSON_Error_Unmarshal(t *testing.T) {
 	assert.EqualError(t, err, "json: cannot unmarshal string into Go struct field .Foo of type int")
 	assert.Zero(t, *output)
 }
+
+func TestResponse_Body_Streaming(t *testing.T) {
+	bodyBytes := []byte("foobarbaz")
+
+	resp := Response{
+		body: newResponseReader(io.NopCloser(bytes.NewReader(bodyBytes))),
+	}
+	require.NoError(t, Streaming().ProcessResponse(&resp))
+
+	// nothing read yet, so it can be reset
+	require.NoError(t, resp.Reset())
+
+	b, err := io.ReadAll(&resp)
+	require.NoError(t, err)
+	assert.Equal(t, bodyBytes, b)
+	assert.Zero(t, resp.body.buffer.Len())
+	assert.Empty(t, resp.body.buffer.buffer)
+
+	assert.Equal(t, ErrBodyNotBuffered, resp.Reset())
+}
+
+func TestResponse_Body_BufferUpTo(t *testing.T) {
+	bodyBytes := []byte("foobarbaz")
+
+	resp := Response{
+		body: newResponseReader(io.NopCloser(bytes.NewReader(bodyBytes))),
+	}
+	require.NoError(t, BufferUpTo(9).ProcessResponse(&resp))
+
+	b, err := io.ReadAll(&resp)
+	require.NoError(t, err)
+	assert.Equal(t, bodyBytes, b)
+	require.NoError(t, resp.Reset())
+
+	b, err = io.ReadAll(&resp)
+	require.NoError(t, err)
+	assert.Equal(t, bodyBytes, b)
+
+	resp = Response{
+		body: newResponseReader(io.NopCloser(bytes.NewReader(bodyBytes))),
+	}
+	require.NoError(t, BufferUpTo(8).ProcessResponse(&resp))
+
+	b, err = io.ReadAll(&resp)
+	require.NoError(t, err)
+	assert.Equal(t, bodyBytes, b)
+	assert.Equal(t, ErrBodyNotBuffered, resp.Reset())
+}
+
+func TestResponse_Body_PartialReset(t *testing.T) {
+	bodyBytes := []byte("foobarbaz")
+
+	resp := Response{
+		body: newResponseReader(io.NopCloser(bytes.NewReader(bodyBytes))),
+	}
+	p := make([]byte, 3)
+	_, err := io.ReadFull(&resp, p)
+	require.NoError(t, err)
+	require.NoError(t, resp.Reset())
+
+	b, err := io.ReadAll(&resp)
+	require.NoError(t, err)
+	assert.Equal(t, bodyBytes, b)
+}
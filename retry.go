@@ -0,0 +1,178 @@
+package http
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/rand"
+	stdhttp "net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy configures how a request is re-sent after a failure
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of times the request is sent, including the first attempt
+	MaxAttempts int
+	// MinBackoff is the delay before the first retry. It doubles with every subsequent retry
+	MinBackoff time.Duration
+	// MaxBackoff caps the delay between two attempts
+	MaxBackoff time.Duration
+	// Jitter is the fraction (between 0 and 1) of each delay that is randomised
+	Jitter float64
+	// MaxElapsed bounds the total time spent sending the request, including delays.
+	// Zero means the request is only bounded by the deadline of its context
+	MaxElapsed time.Duration
+	// Statuses are the response status codes that cause the request to be retried.
+	// Transport errors are always retried, except ErrCircuitOpen
+	Statuses []Status
+}
+
+// DefaultRetryPolicy sends a request up to 3 times, retrying on transport errors and on
+// 429, 502, 503 and 504 responses, with exponential backoff starting at 100ms
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	MinBackoff:  100 * time.Millisecond,
+	MaxBackoff:  10 * time.Second,
+	Jitter:      0.2,
+	Statuses: []Status{
+		StatusTooManyRequests,
+		StatusBadGateway,
+		StatusServiceUnavailable,
+		StatusGatewayTimeout,
+	},
+}
+
+type RetryOption struct {
+	policy RetryPolicy
+}
+
+// Retry is an option to re-send a request on transport errors and retryable statuses.
+// Request bodies are replayed using Request.GetBody, or buffered in memory if it is not set.
+// Retry-After headers on responses take precedence over the backoff of the policy
+func Retry(policy RetryPolicy) RetryOption {
+	return RetryOption{policy}
+}
+
+func (o RetryOption) ModifyRequest(r *Request) error {
+	policy := o.policy
+	r.retry = &policy
+	return nil
+}
+
+func (o RetryOption) ModifyClient(c *Client) {
+	PreRequestMiddlewares(o).ModifyClient(c)
+}
+
+func (p *RetryPolicy) do(client Doer, req *stdhttp.Request) (*stdhttp.Response, error) {
+	if req.Body != nil && req.Body != stdhttp.NoBody && req.GetBody == nil {
+		if err := bufferBody(req); err != nil {
+			return nil, err
+		}
+	}
+
+	ctx := req.Context()
+	start := time.Now()
+
+	for attempt := 1; ; attempt++ {
+		resp, err := client.Do(req)
+		if attempt >= p.MaxAttempts || ctx.Err() != nil || !p.shouldRetry(resp, err) {
+			return resp, err
+		}
+
+		delay := p.backoff(attempt)
+		if d, ok := retryAfter(resp); ok {
+			delay = d
+		}
+		if p.MaxElapsed > 0 && time.Since(start)+delay > p.MaxElapsed {
+			return resp, err
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Now().Add(delay).After(deadline) {
+			return resp, err
+		}
+
+		if resp != nil {
+			// drain a little of the body so the connection can be re-used
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+			_ = resp.Body.Close()
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+
+		req = req.Clone(ctx)
+		if req.GetBody != nil {
+			if req.Body, err = req.GetBody(); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+func (p *RetryPolicy) shouldRetry(resp *stdhttp.Response, err error) bool {
+	if err != nil {
+		// retrying would fail straight away
+		return !errors.Is(err, ErrCircuitOpen)
+	}
+	for _, status := range p.Statuses {
+		if Status(resp.StatusCode) == status {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff returns the delay to wait after the given attempt
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.MinBackoff
+	for i := 1; i < attempt && (p.MaxBackoff <= 0 || delay < p.MaxBackoff); i++ {
+		delay *= 2
+	}
+	if p.MaxBackoff > 0 && delay > p.MaxBackoff {
+		delay = p.MaxBackoff
+	}
+	if p.Jitter > 0 {
+		delay -= time.Duration(p.Jitter * rand.Float64() * float64(delay))
+	}
+	return delay
+}
+
+// retryAfter parses the Retry-After header of the response, either in seconds or as a HTTP date
+func retryAfter(resp *stdhttp.Response) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+	header := resp.Header.Get("Retry-After")
+	if header == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(header); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if t, err := stdhttp.ParseTime(header); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// bufferBody reads the request body into memory so that it can be replayed
+func bufferBody(req *stdhttp.Request) error {
+	b, err := io.ReadAll(req.Body)
+	_ = req.Body.Close()
+	if err != nil {
+		return err
+	}
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(b)), nil
+	}
+	req.Body, _ = req.GetBody()
+	return nil
+}
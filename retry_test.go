@@ -0,0 +1,111 @@
+package http_test
+
+import (
+	"context"
+	"io"
+	stdhttp "net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/conradludgate/go-http"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func fastRetry(attempts int) http.RetryOption {
+	return http.Retry(http.RetryPolicy{
+		MaxAttempts: attempts,
+		MinBackoff:  time.Millisecond,
+		MaxBackoff:  5 * time.Millisecond,
+		Statuses:    []http.Status{http.StatusServiceUnavailable},
+	})
+}
+
+func TestRetry_ReplaysBody(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(stdhttp.HandlerFunc(func(w stdhttp.ResponseWriter, r *stdhttp.Request) {
+		b, _ := io.ReadAll(r.Body)
+		assert.Equal(t, "hello", string(b))
+		if atomic.AddInt32(&attempts, 1) < 3 {
+			w.WriteHeader(stdhttp.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte("done"))
+	}))
+	defer server.Close()
+
+	client := http.NewClient(http.URLString(server.URL), fastRetry(3))
+
+	for _, body := range []io.Reader{strings.NewReader("hello"), io.MultiReader(strings.NewReader("hello"))} {
+		atomic.StoreInt32(&attempts, 0)
+		resp, err := client.Post(http.Body(body)).Send(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.EqualValues(t, 3, atomic.LoadInt32(&attempts))
+
+		b, err := io.ReadAll(resp)
+		require.NoError(t, err)
+		assert.Equal(t, "done", string(b))
+	}
+}
+
+func TestRetry_MaxAttempts(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(stdhttp.HandlerFunc(func(w stdhttp.ResponseWriter, r *stdhttp.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(stdhttp.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := http.NewClient(http.URLString(server.URL), fastRetry(5))
+
+	// request level options override the client policy
+	resp, err := client.Get(fastRetry(2)).Send(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.EqualValues(t, 2, atomic.LoadInt32(&attempts))
+}
+
+func TestRetry_TransportError(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(stdhttp.HandlerFunc(func(w stdhttp.ResponseWriter, r *stdhttp.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			conn, _, err := w.(stdhttp.Hijacker).Hijack()
+			require.NoError(t, err)
+			_ = conn.Close()
+			return
+		}
+		w.WriteHeader(stdhttp.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client := http.NewClient(http.URLString(server.URL), fastRetry(3))
+	resp, err := client.Get().Send(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	assert.EqualValues(t, 2, atomic.LoadInt32(&attempts))
+}
+
+func TestRetry_RetryAfterDeadline(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(stdhttp.HandlerFunc(func(w stdhttp.ResponseWriter, r *stdhttp.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(stdhttp.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := http.NewClient(http.URLString(server.URL), fastRetry(3))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// waiting 60 seconds would exceed the deadline so the response is returned as is
+	resp, err := client.Get().Send(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.EqualValues(t, 1, atomic.LoadInt32(&attempts))
+}
@@ -0,0 +1,647 @@
+package http
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"math"
+	"math/big"
+	stdhttp "net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidSignature is returned when a response does not have a valid HTTP message signature
+var ErrInvalidSignature = errors.New("invalid signature")
+
+// MessageSigner signs HTTP messages with a key
+type MessageSigner interface {
+	KeyID() string
+	// Algorithm is the name of the algorithm from the HTTP Signature Algorithms registry, such as ed25519
+	Algorithm() string
+	Sign(base []byte) ([]byte, error)
+}
+
+// MessageVerifier verifies the signatures of HTTP messages with a key
+type MessageVerifier interface {
+	KeyID() string
+	Algorithm() string
+	// Verify returns an error if the signature is not valid
+	Verify(base, signature []byte) error
+}
+
+type hmacKey struct {
+	keyID string
+	key   []byte
+}
+
+// NewHMACSigner creates a MessageSigner using HMAC-SHA256 with the shared key
+func NewHMACSigner(keyID string, key []byte) MessageSigner {
+	return hmacKey{keyID, key}
+}
+
+// NewHMACVerifier creates a MessageVerifier using HMAC-SHA256 with the shared key
+func NewHMACVerifier(keyID string, key []byte) MessageVerifier {
+	return hmacKey{keyID, key}
+}
+
+func (k hmacKey) KeyID() string     { return k.keyID }
+func (k hmacKey) Algorithm() string { return "hmac-sha256" }
+
+func (k hmacKey) Sign(base []byte) ([]byte, error) {
+	mac := hmac.New(sha256.New, k.key)
+	mac.Write(base)
+	return mac.Sum(nil), nil
+}
+
+func (k hmacKey) Verify(base, signature []byte) error {
+	expected, _ := k.Sign(base)
+	if !hmac.Equal(expected, signature) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+type ed25519Key struct {
+	keyID   string
+	private ed25519.PrivateKey
+	public  ed25519.PublicKey
+}
+
+// NewEd25519Signer creates a MessageSigner using the Ed25519 private key
+func NewEd25519Signer(keyID string, key ed25519.PrivateKey) MessageSigner {
+	return ed25519Key{keyID: keyID, private: key}
+}
+
+// NewEd25519Verifier creates a MessageVerifier using the Ed25519 public key
+func NewEd25519Verifier(keyID string, key ed25519.PublicKey) MessageVerifier {
+	return ed25519Key{keyID: keyID, public: key}
+}
+
+func (k ed25519Key) KeyID() string     { return k.keyID }
+func (k ed25519Key) Algorithm() string { return "ed25519" }
+
+func (k ed25519Key) Sign(base []byte) ([]byte, error) {
+	return ed25519.Sign(k.private, base), nil
+}
+
+func (k ed25519Key) Verify(base, signature []byte) error {
+	if !ed25519.Verify(k.public, base, signature) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+type ecdsaKey struct {
+	keyID   string
+	private *ecdsa.PrivateKey
+	public  *ecdsa.PublicKey
+}
+
+// NewECDSASigner creates a MessageSigner using the ECDSA P-256 private key with SHA-256
+func NewECDSASigner(keyID string, key *ecdsa.PrivateKey) MessageSigner {
+	return ecdsaKey{keyID: keyID, private: key}
+}
+
+// NewECDSAVerifier creates a MessageVerifier using the ECDSA P-256 public key with SHA-256
+func NewECDSAVerifier(keyID string, key *ecdsa.PublicKey) MessageVerifier {
+	return ecdsaKey{keyID: keyID, public: key}
+}
+
+func (k ecdsaKey) KeyID() string     { return k.keyID }
+func (k ecdsaKey) Algorithm() string { return "ecdsa-p256-sha256" }
+
+// Sign returns the signature as the concatenation of r and s, 32 bytes each
+func (k ecdsaKey) Sign(base []byte) ([]byte, error) {
+	digest := sha256.Sum256(base)
+	r, s, err := ecdsa.Sign(rand.Reader, k.private, digest[:])
+	if err != nil {
+		return nil, err
+	}
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	s.FillBytes(signature[32:])
+	return signature, nil
+}
+
+func (k ecdsaKey) Verify(base, signature []byte) error {
+	if len(signature) != 64 {
+		return ErrInvalidSignature
+	}
+	digest := sha256.Sum256(base)
+	r := new(big.Int).SetBytes(signature[:32])
+	s := new(big.Int).SetBytes(signature[32:])
+	if !ecdsa.Verify(k.public, digest[:], r, s) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+type SignOption struct {
+	signer     MessageSigner
+	label      string
+	components []string
+	tag        string
+	expires    time.Duration
+
+	now func() time.Time
+}
+
+// Sign is an option to sign requests with a HTTP message signature (RFC 9421),
+// adding the Signature-Input and Signature headers.
+// Like SigV4, it runs after all the other request middlewares of the client.
+//
+// By default, the signature covers the method, authority, path and query of the request,
+// and its Content-Type and Content-Digest headers if it has a body.
+// The Content-Digest header is computed if it is covered but missing
+func Sign(signer MessageSigner) SignOption {
+	return SignOption{signer: signer, label: "sig1"}
+}
+
+// Label returns a copy of the option using the label to name the signature. Defaults to sig1
+func (s SignOption) Label(label string) SignOption {
+	s.label = label
+	return s
+}
+
+// Components returns a copy of the option covering the components,
+// such as "@method", "@target-uri", "content-type" or `@query-param;name="id"`
+func (s SignOption) Components(components ...string) SignOption {
+	s.components = components
+	return s
+}
+
+// Tag returns a copy of the option adding the tag parameter to the signature, to tell its use apart
+func (s SignOption) Tag(tag string) SignOption {
+	s.tag = tag
+	return s
+}
+
+// Expires returns a copy of the option adding an expiry time to the signature
+func (s SignOption) Expires(d time.Duration) SignOption {
+	s.expires = d
+	return s
+}
+
+// Order makes the signature the last of the request middlewares
+func (s SignOption) Order() int {
+	return math.MaxInt32
+}
+
+func (s SignOption) ModifyClient(c *Client) {
+	PostRequestMiddlewares(s).ModifyClient(c)
+}
+
+func (s SignOption) ModifyRequest(r *Request) error {
+	if r.URL == nil {
+		return fmt.Errorf("cannot sign request because there's no url")
+	}
+	if r.Headers == nil {
+		r.Headers = make(stdhttp.Header)
+	}
+
+	names := s.components
+	if names == nil {
+		names = []string{"@method", "@authority", "@path"}
+		if r.URL.RawQuery != "" {
+			names = append(names, "@query")
+		}
+		if r.Body != nil {
+			if r.Headers.Get("Content-Type") != "" {
+				names = append(names, "content-type")
+			}
+			names = append(names, "content-digest")
+		}
+	}
+	components := make([]component, len(names))
+	for i, name := range names {
+		c, err := parseComponent(name)
+		if err != nil {
+			return err
+		}
+		if c.name == "content-digest" && r.Headers.Get("Content-Digest") == "" {
+			sum, err := hashRequestBody(r)
+			if err != nil {
+				return fmt.Errorf("cannot hash request body: %w", err)
+			}
+			r.Headers.Set("Content-Digest", "sha-256=:"+base64.StdEncoding.EncodeToString(sum)+":")
+		}
+		components[i] = c
+	}
+
+	now := time.Now
+	if s.now != nil {
+		now = s.now
+	}
+	created := now()
+	params := fmt.Sprintf(";created=%d", created.Unix())
+	if s.expires > 0 {
+		params += fmt.Sprintf(";expires=%d", created.Add(s.expires).Unix())
+	}
+	params += ";keyid=" + strconv.Quote(s.signer.KeyID())
+	if s.tag != "" {
+		params += ";tag=" + strconv.Quote(s.tag)
+	}
+
+	msg := requestMessage(r)
+	base, signatureParams, err := signatureBase(msg, components, params)
+	if err != nil {
+		return err
+	}
+	signature, err := s.signer.Sign([]byte(base))
+	if err != nil {
+		return fmt.Errorf("cannot sign request: %w", err)
+	}
+
+	setDictionaryMember(r.Headers, "Signature-Input", s.label, signatureParams)
+	setDictionaryMember(r.Headers, "Signature", s.label, ":"+base64.StdEncoding.EncodeToString(signature)+":")
+	return nil
+}
+
+// setDictionaryMember sets the member of the dictionary header, replacing any member with the same key
+func setDictionaryMember(headers stdhttp.Header, name, key, value string) {
+	var members []string
+	for _, member := range parseDictionary(strings.Join(headers.Values(name), ", ")) {
+		if member.key != key {
+			members = append(members, member.key+"="+member.value)
+		}
+	}
+	headers.Set(name, strings.Join(append(members, key+"="+value), ", "))
+}
+
+type VerifySignatureOption struct {
+	verifier MessageVerifier
+	label    string
+	required []string
+	maxAge   time.Duration
+}
+
+// VerifySignature is an option to fail a response with an error matching ErrInvalidSignature
+// unless it has a valid HTTP message signature (RFC 9421) from the verifier's key.
+// The signature is found by its keyid parameter, or by its label if one is given.
+// Unless other components are required, the signature must cover @status, and content-digest
+// if the response has a body. If the signature covers the Content-Digest header, the body is checked against it
+func VerifySignature(verifier MessageVerifier) VerifySignatureOption {
+	return VerifySignatureOption{verifier: verifier}
+}
+
+// Label returns a copy of the option verifying the signature with the label
+func (v VerifySignatureOption) Label(label string) VerifySignatureOption {
+	v.label = label
+	return v
+}
+
+// Require returns a copy of the option failing signatures that don't cover all of the components,
+// replacing the default components
+func (v VerifySignatureOption) Require(components ...string) VerifySignatureOption {
+	v.required = components
+	return v
+}
+
+// MaxAge returns a copy of the option failing signatures created longer than d ago
+func (v VerifySignatureOption) MaxAge(d time.Duration) VerifySignatureOption {
+	v.maxAge = d
+	return v
+}
+
+func (v VerifySignatureOption) ProcessResponse(resp *Response) error {
+	if err := v.verify(resp); err != nil {
+		if errors.Is(err, ErrInvalidSignature) {
+			return err
+		}
+		return fmt.Errorf("%w: %s", ErrInvalidSignature, err)
+	}
+	return nil
+}
+
+func (v VerifySignatureOption) verify(resp *Response) error {
+	inputs := parseDictionary(strings.Join(resp.Headers.Values("Signature-Input"), ", "))
+	signatures := parseDictionary(strings.Join(resp.Headers.Values("Signature"), ", "))
+
+	label, input := "", ""
+	for _, member := range inputs {
+		if v.label != "" && member.key == v.label {
+			label, input = member.key, member.value
+			break
+		}
+		if v.label == "" && sigParam(member.value, "keyid") == v.verifier.KeyID() {
+			label, input = member.key, member.value
+			break
+		}
+	}
+	if label == "" {
+		return fmt.Errorf("no signature from key %s", v.verifier.KeyID())
+	}
+	var encoded string
+	for _, member := range signatures {
+		if member.key == label {
+			encoded = member.value
+		}
+	}
+	signature, err := base64.StdEncoding.DecodeString(strings.Trim(encoded, ":"))
+	if err != nil || encoded == "" {
+		return fmt.Errorf("missing or invalid signature %s", label)
+	}
+
+	if alg := sigParam(input, "alg"); alg != "" && alg != v.verifier.Algorithm() {
+		return fmt.Errorf("signature %s uses algorithm %s, expected %s", label, alg, v.verifier.Algorithm())
+	}
+	now := time.Now()
+	if expires, err := strconv.ParseInt(sigParam(input, "expires"), 10, 64); err == nil && now.After(time.Unix(expires, 0)) {
+		return fmt.Errorf("signature %s has expired", label)
+	}
+	if v.maxAge > 0 {
+		created, err := strconv.ParseInt(sigParam(input, "created"), 10, 64)
+		if err != nil || now.Sub(time.Unix(created, 0)) > v.maxAge {
+			return fmt.Errorf("signature %s is too old", label)
+		}
+	}
+
+	end := strings.IndexByte(input, ')')
+	if !strings.HasPrefix(input, "(") || end < 0 {
+		return fmt.Errorf("invalid signature input %s", label)
+	}
+	var components []component
+	for _, item := range splitOutsideQuotes(input[1:end], ' ') {
+		if item == "" {
+			continue
+		}
+		c, err := parseComponent(item)
+		if err != nil {
+			return err
+		}
+		components = append(components, c)
+	}
+	if len(components) == 0 {
+		return fmt.Errorf("signature %s covers no components", label)
+	}
+	required := v.required
+	if required == nil {
+		required = []string{"@status"}
+		if resp.ContentLength != 0 {
+			required = append(required, "content-digest")
+		}
+	}
+	for _, name := range required {
+		required, err := parseComponent(name)
+		if err != nil {
+			return err
+		}
+		covered := false
+		for _, c := range components {
+			covered = covered || c.id == required.id
+		}
+		if !covered {
+			return fmt.Errorf("signature %s does not cover %s", label, required.id)
+		}
+	}
+
+	msg := &message{status: int(resp.StatusCode), headers: resp.Headers, contentLength: resp.ContentLength}
+	if resp.Request != nil {
+		msg.request = requestMessage(resp.Request)
+	}
+	base, _, err := signatureBase(msg, components, input[end+1:])
+	if err != nil {
+		return err
+	}
+	if err := v.verifier.Verify([]byte(base), signature); err != nil {
+		return err
+	}
+
+	for _, c := range components {
+		if c.name == "content-digest" && !c.req {
+			return verifyContentDigest(resp)
+		}
+	}
+	return nil
+}
+
+// verifyContentDigest checks the response body against its sha-256 or sha-512 Content-Digest
+func verifyContentDigest(resp *Response) error {
+	for _, member := range parseDictionary(resp.Headers.Get("Content-Digest")) {
+		var h hash.Hash
+		switch member.key {
+		case "sha-256":
+			h = sha256.New()
+		case "sha-512":
+			h = sha512.New()
+		default:
+			continue
+		}
+		expected, err := base64.StdEncoding.DecodeString(strings.Trim(member.value, ":"))
+		if err != nil {
+			return fmt.Errorf("invalid Content-Digest header")
+		}
+		_, err = io.Copy(h, resp)
+		if rerr := resp.Reset(); err == nil {
+			err = rerr
+		}
+		if err != nil {
+			return fmt.Errorf("cannot read response body: %w", err)
+		}
+		if !hmac.Equal(h.Sum(nil), expected) {
+			return fmt.Errorf("%w: body does not match Content-Digest", ErrInvalidSignature)
+		}
+		return nil
+	}
+	return fmt.Errorf("no supported algorithm in Content-Digest header")
+}
+
+// message holds the parts of a request or response that can be covered by a signature
+type message struct {
+	method        string
+	url           *url.URL
+	status        int
+	headers       stdhttp.Header
+	contentLength int64
+	// request is the request a response is for
+	request *message
+}
+
+func requestMessage(r *Request) *message {
+	return &message{method: string(r.Method), url: r.URL, headers: r.Headers, contentLength: r.ContentLength}
+}
+
+// component is a component identifier, such as "@method" or "content-type";req
+type component struct {
+	// id is the serialized identifier
+	id   string
+	name string
+	req  bool
+	// param is the name parameter of @query-param
+	param string
+}
+
+// parseComponent parses an identifier, with or without quotes around its name
+func parseComponent(s string) (component, error) {
+	parts := splitOutsideQuotes(s, ';')
+	c := component{name: strings.ToLower(strings.Trim(strings.TrimSpace(parts[0]), `"`))}
+	c.id = strconv.Quote(c.name)
+	for _, param := range parts[1:] {
+		key, value := param, ""
+		if eq := strings.IndexByte(param, '='); eq >= 0 {
+			key, value = param[:eq], param[eq+1:]
+		}
+		switch {
+		case key == "req" && value == "":
+			c.req = true
+			c.id += ";req"
+		case key == "name" && c.name == "@query-param":
+			unquoted, err := strconv.Unquote(value)
+			if err != nil {
+				return c, fmt.Errorf("invalid component %s", s)
+			}
+			c.param = unquoted
+			c.id += ";name=" + strconv.Quote(unquoted)
+		default:
+			return c, fmt.Errorf("unsupported component parameter %s in %s", key, s)
+		}
+	}
+	return c, nil
+}
+
+// value returns the value of the component in the message
+func (m *message) value(c component) (string, error) {
+	if c.req {
+		if m.request == nil {
+			return "", fmt.Errorf("component %s needs the request of a response", c.id)
+		}
+		m = m.request
+	}
+
+	if !strings.HasPrefix(c.name, "@") {
+		values := m.headers.Values(c.name)
+		if len(values) == 0 && c.name == "content-length" && m.contentLength > 0 {
+			return strconv.FormatInt(m.contentLength, 10), nil
+		}
+		if len(values) == 0 {
+			return "", fmt.Errorf("cannot sign missing header %s", c.name)
+		}
+		trimmed := make([]string, len(values))
+		for i, v := range values {
+			trimmed[i] = strings.TrimSpace(v)
+		}
+		return strings.Join(trimmed, ", "), nil
+	}
+
+	if c.name == "@status" {
+		if m.status == 0 {
+			return "", fmt.Errorf("component @status is only for responses")
+		}
+		return strconv.Itoa(m.status), nil
+	}
+	if m.url == nil {
+		return "", fmt.Errorf("component %s is only for requests", c.id)
+	}
+	switch c.name {
+	case "@method":
+		return strings.ToUpper(m.method), nil
+	case "@target-uri":
+		return m.url.String(), nil
+	case "@authority":
+		return strings.ToLower(m.url.Host), nil
+	case "@scheme":
+		return strings.ToLower(m.url.Scheme), nil
+	case "@request-target":
+		return m.url.RequestURI(), nil
+	case "@path":
+		if p := m.url.EscapedPath(); p != "" {
+			return p, nil
+		}
+		return "/", nil
+	case "@query":
+		return "?" + m.url.RawQuery, nil
+	case "@query-param":
+		values, ok := m.url.Query()[c.param]
+		if !ok || len(values) != 1 {
+			return "", fmt.Errorf("cannot sign query parameter %s, it must be present once", c.param)
+		}
+		return strings.ReplaceAll(url.QueryEscape(values[0]), "+", "%20"), nil
+	}
+	return "", fmt.Errorf("unsupported component %s", c.name)
+}
+
+// signatureBase creates the signature base (RFC 9421, 2.5) and the serialized signature parameters
+func signatureBase(m *message, components []component, params string) (string, string, error) {
+	var base strings.Builder
+	ids := make([]string, len(components))
+	for i, c := range components {
+		value, err := m.value(c)
+		if err != nil {
+			return "", "", err
+		}
+		base.WriteString(c.id + ": " + value + "\n")
+		ids[i] = c.id
+	}
+	signatureParams := "(" + strings.Join(ids, " ") + ")" + params
+	base.WriteString(`"@signature-params": ` + signatureParams)
+	return base.String(), signatureParams, nil
+}
+
+type dictionaryMember struct {
+	key   string
+	value string
+}
+
+// parseDictionary splits a structured field dictionary into its members, keeping their values serialized
+func parseDictionary(s string) []dictionaryMember {
+	var members []dictionaryMember
+	for _, member := range splitOutsideQuotes(s, ',') {
+		member = strings.TrimSpace(member)
+		if eq := strings.IndexByte(member, '='); eq > 0 {
+			members = append(members, dictionaryMember{member[:eq], member[eq+1:]})
+		}
+	}
+	return members
+}
+
+// sigParam returns the unquoted value of a parameter of the inner list of a signature input
+func sigParam(input, key string) string {
+	end := strings.LastIndexByte(input, ')')
+	for _, param := range splitOutsideQuotes(input[end+1:], ';') {
+		if eq := strings.IndexByte(param, '='); eq >= 0 && strings.TrimSpace(param[:eq]) == key {
+			value := param[eq+1:]
+			if unquoted, err := strconv.Unquote(value); err == nil {
+				return unquoted
+			}
+			return value
+		}
+	}
+	return ""
+}
+
+// splitOutsideQuotes splits s on sep, except inside quoted strings and parentheses
+func splitOutsideQuotes(s string, sep byte) []string {
+	var parts []string
+	quoted, escaped, depth, start := false, false, 0, 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case escaped:
+			escaped = false
+		case quoted && c == '\\':
+			escaped = true
+		case c == '"':
+			quoted = !quoted
+		case quoted:
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == sep && depth == 0:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
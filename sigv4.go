@@ -0,0 +1,256 @@
+package http
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math"
+	stdhttp "net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// AWSCredentials are the credentials used to sign requests with SigV4
+type AWSCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	// SessionToken is set for temporary credentials
+	SessionToken string
+}
+
+type SigV4Option struct {
+	credentials AWSCredentials
+	region      string
+	service     string
+
+	now func() time.Time
+}
+
+// SigV4 is an option to sign requests with AWS Signature Version 4.
+// It must be given to the client, where it runs after all the other PostRequestMiddlewares
+// so that the signature covers their changes. Request bodies are read into memory to be hashed if they
+// have no GetBody function. For the s3 service, the X-Amz-Content-Sha256 header is set as well
+func SigV4(credentials AWSCredentials, region, service string) SigV4Option {
+	return SigV4Option{credentials: credentials, region: region, service: service}
+}
+
+// Order makes the signature the last of the request middlewares
+func (s SigV4Option) Order() int {
+	return math.MaxInt32
+}
+
+func (s SigV4Option) ModifyClient(c *Client) {
+	PostRequestMiddlewares(s).ModifyClient(c)
+}
+
+// sigV4IgnoredHeaders are not signed as they are commonly changed on the way to the server
+var sigV4IgnoredHeaders = map[string]bool{
+	"authorization":     true,
+	"user-agent":        true,
+	"x-amzn-trace-id":   true,
+	"expect":            true,
+	"content-length":    true,
+	"connection":        true,
+	"transfer-encoding": true,
+}
+
+func (s SigV4Option) ModifyRequest(r *Request) error {
+	if r.URL == nil {
+		return fmt.Errorf("cannot sign request because there's no url")
+	}
+
+	now := time.Now
+	if s.now != nil {
+		now = s.now
+	}
+	t := now().UTC()
+	amzDate := t.Format("20060102T150405Z")
+	scope := strings.Join([]string{t.Format("20060102"), s.region, s.service, "aws4_request"}, "/")
+
+	sum, err := hashRequestBody(r)
+	if err != nil {
+		return fmt.Errorf("cannot hash request body: %w", err)
+	}
+	payloadHash := hex.EncodeToString(sum)
+
+	if r.Headers == nil {
+		r.Headers = make(stdhttp.Header)
+	}
+	r.Headers.Set("X-Amz-Date", amzDate)
+	if s.credentials.SessionToken != "" {
+		r.Headers.Set("X-Amz-Security-Token", s.credentials.SessionToken)
+	}
+	if s.service == "s3" {
+		r.Headers.Set("X-Amz-Content-Sha256", payloadHash)
+	}
+
+	// send the query as it is signed, as url.Values encodes spaces as + rather than %20
+	query := canonicalQuery(r.URL)
+	r.URL.RawQuery = query
+
+	canonicalHeaders, signedHeaders := s.canonicalHeaders(r)
+	canonicalRequest := strings.Join([]string{
+		string(r.Method),
+		s.canonicalURI(r.URL),
+		query,
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hexSHA256([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := []byte("AWS4" + s.credentials.SecretAccessKey)
+	for _, part := range strings.Split(scope, "/") {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	r.Headers.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.credentials.AccessKeyID, scope, signedHeaders, signature))
+	return nil
+}
+
+// canonicalURI encodes the path of the url. Paths are encoded twice, except for s3
+func (s SigV4Option) canonicalURI(u *url.URL) string {
+	p := u.EscapedPath()
+	if p == "" {
+		return "/"
+	}
+	if s.service == "s3" {
+		return p
+	}
+	clean := path.Clean(p)
+	if strings.HasSuffix(p, "/") && clean != "/" {
+		clean += "/"
+	}
+	return uriEncode(clean, false)
+}
+
+// canonicalQuery encodes the query parameters, sorted by key then value
+func canonicalQuery(u *url.URL) string {
+	query, _ := url.ParseQuery(u.RawQuery)
+	params := make([][2]string, 0, len(query))
+	for k, vs := range query {
+		for _, v := range vs {
+			params = append(params, [2]string{uriEncode(k, true), uriEncode(v, true)})
+		}
+	}
+	sort.Slice(params, func(i, j int) bool {
+		if params[i][0] != params[j][0] {
+			return params[i][0] < params[j][0]
+		}
+		return params[i][1] < params[j][1]
+	})
+
+	encoded := make([]string, len(params))
+	for i, param := range params {
+		encoded[i] = param[0] + "=" + param[1]
+	}
+	return strings.Join(encoded, "&")
+}
+
+// canonicalHeaders returns the headers to sign, one per line, and their names separated by semicolons
+func (s SigV4Option) canonicalHeaders(r *Request) (string, string) {
+	values := map[string]string{"host": r.URL.Host}
+	for k, vs := range r.Headers {
+		name := strings.ToLower(k)
+		if sigV4IgnoredHeaders[name] || name == "host" {
+			continue
+		}
+		trimmed := make([]string, len(vs))
+		for i, v := range vs {
+			trimmed[i] = strings.Join(strings.Fields(v), " ")
+		}
+		values[name] = strings.Join(trimmed, ",")
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonical strings.Builder
+	for _, name := range names {
+		canonical.WriteString(name + ":" + values[name] + "\n")
+	}
+	return canonical.String(), strings.Join(names, ";")
+}
+
+// hashRequestBody returns the SHA-256 digest of the request body, keeping the body in memory
+// if it cannot be read again. The body is replaced with a new one from GetBody
+func hashRequestBody(r *Request) ([]byte, error) {
+	h := sha256.New()
+	if r.Body == nil {
+		return h.Sum(nil), nil
+	}
+	if r.GetBody == nil {
+		b, err := io.ReadAll(r.Body)
+		_ = r.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		r.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(b)), nil
+		}
+		r.Body, _ = r.GetBody()
+		r.ContentLength = int64(len(b))
+	}
+
+	body, err := r.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	_, err = io.Copy(h, body)
+	_ = body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	// send a fresh copy of the body, as the original one may share its readers with GetBody
+	fresh, err := r.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	_ = r.Body.Close()
+	r.Body = fresh
+	return h.Sum(nil), nil
+}
+
+// uriEncode percent encodes everything but unreserved characters, and slashes unless encodeSlash is set
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' ||
+			c == '-' || c == '_' || c == '.' || c == '~' || (c == '/' && !encodeSlash) {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func hexSHA256(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
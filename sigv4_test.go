@@ -0,0 +1,100 @@
+package http
+
+import (
+	"context"
+	"io"
+	stdhttp "net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// exampleSigV4 signs with the credentials of the AWS SigV4 test suite
+func exampleSigV4(service string) SigV4Option {
+	s := SigV4(AWSCredentials{
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+	}, "us-east-1", service)
+	s.now = func() time.Time {
+		return time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+	}
+	return s
+}
+
+func TestSigV4_TestSuite(t *testing.T) {
+	tests := []struct {
+		name      string
+		url       string
+		signature string
+	}{
+		{"get-vanilla", "https://example.amazonaws.com/", "5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"},
+		{"get-vanilla-query-order-key-case", "https://example.amazonaws.com/?Param2=value2&Param1=value1", "b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500"},
+		{"get-query-space", "https://example.amazonaws.com/?prefix=my+file", "acbedd8838200efcf82baeb865615d97807f56cb43ee8c8c56aa6fb0522ec768"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := NewClient(URLString(test.url), exampleSigV4("service"))
+			req := client.Get()
+			require.NoError(t, req.applyOptions(ordered(client.PostRequestMiddlewares)...))
+
+			assert.Equal(t, "20150830T123600Z", req.Headers.Get("X-Amz-Date"))
+			assert.Equal(t, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, "+
+				"SignedHeaders=host;x-amz-date, Signature="+test.signature, req.Headers.Get("Authorization"))
+		})
+	}
+}
+
+func TestSigV4_SendsSignedQuery(t *testing.T) {
+	var query string
+	server := httptest.NewServer(stdhttp.HandlerFunc(func(w stdhttp.ResponseWriter, r *stdhttp.Request) {
+		query = r.URL.RawQuery
+	}))
+	defer server.Close()
+
+	client := NewClient(URLString(server.URL), exampleSigV4("s3"))
+	_, err := client.Get(Param("prefix", "my file"), Param("delimiter", "/")).Send(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "delimiter=%2F&prefix=my%20file", query)
+}
+
+func TestSigV4_Multipart(t *testing.T) {
+	server := httptest.NewServer(stdhttp.HandlerFunc(func(w stdhttp.ResponseWriter, r *stdhttp.Request) {
+		b, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		assert.Equal(t, hexSHA256(b), r.Header.Get("X-Amz-Content-Sha256"))
+	}))
+	defer server.Close()
+
+	client := NewClient(URLString(server.URL), exampleSigV4("s3"))
+	resp, err := client.Post(Multipart(
+		FieldPart("key", "uploads/hello.txt"),
+		FilePart("file", "hello.txt", strings.NewReader("hello world")),
+	)).Send(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, StatusOK, resp.StatusCode)
+}
+
+func TestSigV4_SignsLast(t *testing.T) {
+	var signed stdhttp.Header
+	server := httptest.NewServer(stdhttp.HandlerFunc(func(w stdhttp.ResponseWriter, r *stdhttp.Request) {
+		signed = r.Header.Clone()
+	}))
+	defer server.Close()
+
+	client := NewClient(
+		URLString(server.URL),
+		exampleSigV4("s3"),
+		// added after the signature, but still covered by it
+		HeaderFunc("X-Request-Id", func(*Request) (string, error) { return "abc", nil }),
+	)
+	_, err := client.Put(Body(strings.NewReader("hello"))).Send(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, "abc", signed.Get("X-Request-Id"))
+	assert.Equal(t, "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824", signed.Get("X-Amz-Content-Sha256"))
+	assert.Contains(t, signed.Get("Authorization"), "SignedHeaders=host;x-amz-content-sha256;x-amz-date;x-request-id,")
+}
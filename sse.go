@@ -0,0 +1,214 @@
+package http
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Event is a server-sent event, as parsed from a text/event-stream response
+type Event struct {
+	// ID is the last event ID seen in the stream
+	ID string
+	// Event is the event type, "message" by default
+	Event string
+	Data  string
+	// Retry is the reconnection delay sent along with this event, if any
+	Retry time.Duration
+}
+
+type SSEOption struct {
+	fn func(Event) error
+}
+
+// SSE is an option to parse a text/event-stream response, calling fn for each event until the stream ends.
+// Parsing stops early if fn returns an error, which is then returned from Send.
+// The body is not kept in memory, and is closed once parsing stops.
+// A 204 No Content response contains no events, any other non-200 response fails with a *StatusError
+func SSE(fn func(Event) error) SSEOption {
+	return SSEOption{fn}
+}
+
+func (s SSEOption) ProcessResponse(resp *Response) error {
+	return (&eventStream{fn: s.fn}).ProcessResponse(resp)
+}
+
+// defaultRetry is the reconnection delay used by Subscribe until the server sends one
+const defaultRetry = 3 * time.Second
+
+// eventStream parses events, keeping the state needed to reconnect to the stream
+type eventStream struct {
+	fn func(Event) error
+
+	lastID string
+	retry  time.Duration
+
+	// fnErr is the error returned by fn, if any
+	fnErr error
+	// fatal is set if reconnecting would not help
+	fatal bool
+}
+
+func (s *eventStream) ProcessResponse(resp *Response) error {
+	if resp.StatusCode == StatusNoContent {
+		s.fatal = true
+		return nil
+	}
+	if resp.StatusCode != StatusOK {
+		s.fatal = true
+		return ExpectStatus(StatusOK).ProcessResponse(resp)
+	}
+	if ct := resp.Headers.Get("Content-Type"); mediaType(ct) != "text/event-stream" {
+		s.fatal = true
+		return fmt.Errorf("invalid Content-Type header, expected 'text/event-stream', got %s", ct)
+	}
+
+	resp.body.unbuffered = true
+	defer resp.Close()
+
+	scanner := bufio.NewScanner(resp.body)
+	scanner.Buffer(make([]byte, 4096), 1<<20)
+	scanner.Split(scanLines)
+
+	var event Event
+	var data strings.Builder
+	first := true
+	for scanner.Scan() {
+		line := scanner.Text()
+		if first {
+			line = strings.TrimPrefix(line, "\ufeff")
+			first = false
+		}
+
+		if line == "" {
+			if err := s.dispatch(&event, &data); err != nil {
+				return err
+			}
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue // comment
+		}
+
+		field, value := line, ""
+		if i := strings.IndexByte(line, ':'); i >= 0 {
+			field, value = line[:i], strings.TrimPrefix(line[i+1:], " ")
+		}
+		switch field {
+		case "event":
+			event.Event = value
+		case "data":
+			data.WriteString(value)
+			data.WriteByte('\n')
+		case "id":
+			if !strings.ContainsRune(value, 0) {
+				s.lastID = value
+			}
+		case "retry":
+			if ms, err := strconv.ParseUint(value, 10, 63); err == nil {
+				s.retry = time.Duration(ms) * time.Millisecond
+				event.Retry = s.retry
+			}
+		}
+	}
+	return scanner.Err()
+}
+
+// dispatch sends the buffered event to the callback, resetting the buffers
+func (s *eventStream) dispatch(event *Event, data *strings.Builder) error {
+	defer func() {
+		*event = Event{}
+		data.Reset()
+	}()
+
+	if data.Len() == 0 {
+		return nil
+	}
+	e := *event
+	e.ID = s.lastID
+	e.Data = strings.TrimSuffix(data.String(), "\n")
+	if e.Event == "" {
+		e.Event = "message"
+	}
+
+	if err := s.fn(e); err != nil {
+		s.fnErr = err
+		return err
+	}
+	return nil
+}
+
+// scanLines splits lines ending in \r\n, \n or \r
+func scanLines(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		if data[i] == '\n' {
+			return i + 1, data[:i], nil
+		}
+		if i+1 < len(data) {
+			if data[i+1] == '\n' {
+				return i + 2, data[:i], nil
+			}
+			return i + 1, data[:i], nil
+		}
+		if atEOF {
+			return i + 1, data[:i], nil
+		}
+		return 0, nil, nil // need to know if \r is followed by \n
+	}
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+// Subscribe sends GET requests with the options provided to a server-sent events stream,
+// calling fn for each event. When the stream is disconnected, it reconnects after the delay
+// requested by the server, sending the Last-Event-ID header to resume the stream.
+//
+// Subscribe returns when ctx is done, when fn returns an error, when the server responds with
+// 204 No Content, or when the response cannot be an event stream
+func (c *Client) Subscribe(ctx context.Context, fn func(Event) error, options ...RequestOption) error {
+	stream := &eventStream{fn: fn, retry: defaultRetry}
+
+	for {
+		opts := []RequestOption{
+			SetHeader("Accept", "text/event-stream"),
+			SetHeader("Cache-Control", "no-cache"),
+		}
+		if stream.lastID != "" {
+			opts = append(opts, SetHeader("Last-Event-ID", stream.lastID))
+		}
+		opts = append(opts, options...)
+
+		req := c.Get(opts...)
+		if err := req.Error(); err != nil {
+			return fmt.Errorf("request error: %w", err)
+		}
+
+		_, err := req.Send(ctx, stream)
+		if stream.fnErr != nil {
+			return stream.fnErr
+		}
+		if stream.fatal {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		timer := time.NewTimer(stream.retry)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package http_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	stdhttp "net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/conradludgate/go-http"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSSE(t *testing.T) {
+	server := httptest.NewServer(stdhttp.HandlerFunc(func(w stdhttp.ResponseWriter, r *stdhttp.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, ": comment\n\n")
+		fmt.Fprint(w, "data: first\r\n\r\n")
+		fmt.Fprint(w, "event: update\rid: 1\rdata: line 1\rdata:line 2\r\r")
+		fmt.Fprint(w, "retry: 1500\n\n")
+		fmt.Fprint(w, "data\nid: 2\n\n")
+		fmt.Fprint(w, "data: not dispatched")
+	}))
+	defer server.Close()
+
+	var events []http.Event
+	_, err := http.NewClient(http.URLString(server.URL)).Get().Send(context.Background(), http.SSE(func(e http.Event) error {
+		events = append(events, e)
+		return nil
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, []http.Event{
+		{Event: "message", Data: "first"},
+		{ID: "1", Event: "update", Data: "line 1\nline 2"},
+		{ID: "2", Event: "message", Data: ""},
+	}, events)
+}
+
+func TestSubscribe(t *testing.T) {
+	var mu sync.Mutex
+	var lastEventIDs []string
+
+	server := httptest.NewServer(stdhttp.HandlerFunc(func(w stdhttp.ResponseWriter, r *stdhttp.Request) {
+		assert.Equal(t, "text/event-stream", r.Header.Get("Accept"))
+
+		mu.Lock()
+		lastEventIDs = append(lastEventIDs, r.Header.Get("Last-Event-ID"))
+		n := len(lastEventIDs)
+		mu.Unlock()
+
+		if n == 4 {
+			w.WriteHeader(stdhttp.StatusNoContent)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprintf(w, "retry: 10\nid: %d\ndata: event %d\n\n", n, n)
+	}))
+	defer server.Close()
+
+	client := http.NewClient(http.URLString(server.URL))
+
+	var data []string
+	start := time.Now()
+	err := client.Subscribe(context.Background(), func(e http.Event) error {
+		data = append(data, e.Data)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, int64(time.Since(start)), int64(30*time.Millisecond))
+
+	assert.Equal(t, []string{"event 1", "event 2", "event 3"}, data)
+	assert.Equal(t, []string{"", "1", "2", "3"}, lastEventIDs)
+}
+
+func TestSubscribe_Stop(t *testing.T) {
+	server := httptest.NewServer(stdhttp.HandlerFunc(func(w stdhttp.ResponseWriter, r *stdhttp.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for i := 0; ; i++ {
+			if _, err := fmt.Fprintf(w, "data: %d\n\n", i); err != nil {
+				return
+			}
+			w.(stdhttp.Flusher).Flush()
+			time.Sleep(time.Millisecond)
+		}
+	}))
+	defer server.Close()
+
+	client := http.NewClient(http.URLString(server.URL))
+	errStop := errors.New("stop")
+
+	err := client.Subscribe(context.Background(), func(e http.Event) error {
+		if e.Data == "3" {
+			return errStop
+		}
+		return nil
+	})
+	assert.Equal(t, errStop, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err = client.Subscribe(ctx, func(e http.Event) error { return nil })
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
+
+func TestSubscribe_StatusError(t *testing.T) {
+	server := httptest.NewServer(stdhttp.HandlerFunc(func(w stdhttp.ResponseWriter, r *stdhttp.Request) {
+		w.WriteHeader(stdhttp.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	err := http.NewClient(http.URLString(server.URL)).Subscribe(context.Background(), func(e http.Event) error { return nil })
+	var statusErr *http.StatusError
+	require.True(t, errors.As(err, &statusErr))
+	assert.Equal(t, http.StatusUnauthorized, statusErr.StatusCode)
+}
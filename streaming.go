@@ -0,0 +1,28 @@
+package http
+
+type BufferOption struct {
+	unbuffered bool
+	limit      int
+}
+
+// Streaming is an option to stop the response body from being kept in memory as it is read.
+// This allows reading large bodies, but the response can no longer be Reset once read
+func Streaming() BufferOption {
+	return BufferOption{unbuffered: true}
+}
+
+// BufferUpTo is an option to keep at most n bytes of the response body in memory as it is read.
+// If the body is larger, it stops being kept and Reset returns ErrBodyNotBuffered
+func BufferUpTo(n int) BufferOption {
+	return BufferOption{limit: n}
+}
+
+func (b BufferOption) ProcessResponse(resp *Response) error {
+	resp.body.unbuffered = b.unbuffered
+	resp.body.limit = b.limit
+	return nil
+}
+
+func (b BufferOption) ModifyClient(c *Client) {
+	PreResponseMiddlewares(b).ModifyClient(c)
+}
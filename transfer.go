@@ -0,0 +1,136 @@
+package http
+
+import (
+	"io"
+	"time"
+)
+
+// wrapBody wraps the request body and any copies of it made to re-send the request
+func wrapBody(r *Request, wrap func(io.ReadCloser) io.ReadCloser) {
+	if r.Body == nil {
+		return
+	}
+	r.Body = wrap(r.Body)
+	if getBody := r.GetBody; getBody != nil {
+		r.GetBody = func() (io.ReadCloser, error) {
+			body, err := getBody()
+			if err != nil {
+				return nil, err
+			}
+			return wrap(body), nil
+		}
+	}
+}
+
+// wrapResponseBody wraps the part of the response body that is still to be read
+func wrapResponseBody(resp *Response, wrap func(io.ReadCloser) io.ReadCloser) {
+	if resp.body.reader != nil {
+		resp.body.reader = wrap(resp.body.reader)
+	}
+}
+
+type BodyProgressOption struct {
+	fn func(sent, total int64)
+}
+
+// BodyProgress is an option to report the progress of a body as it is transferred.
+// On requests, it must come after the option that sets the body, and total is the Content-Length of the request.
+// On responses, total is the Content-Length of the response.
+// In both cases, total is -1 if the size of the body is not known
+func BodyProgress(fn func(sent, total int64)) BodyProgressOption {
+	return BodyProgressOption{fn}
+}
+
+func (b BodyProgressOption) ModifyRequest(r *Request) error {
+	total := r.ContentLength
+	if total <= 0 {
+		total = -1
+	}
+	wrapBody(r, func(rc io.ReadCloser) io.ReadCloser {
+		return &progressReader{ReadCloser: rc, fn: b.fn, total: total}
+	})
+	return nil
+}
+
+func (b BodyProgressOption) ProcessResponse(resp *Response) error {
+	wrapResponseBody(resp, func(rc io.ReadCloser) io.ReadCloser {
+		return &progressReader{ReadCloser: rc, fn: b.fn, total: resp.ContentLength}
+	})
+	return nil
+}
+
+type progressReader struct {
+	io.ReadCloser
+	fn    func(sent, total int64)
+	sent  int64
+	total int64
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.ReadCloser.Read(b)
+	if n > 0 {
+		p.sent += int64(n)
+		p.fn(p.sent, p.total)
+	}
+	return n, err
+}
+
+type BodyRateLimitOption struct {
+	bytesPerSec int64
+}
+
+// BodyRateLimit is an option to limit the bandwidth used to transfer a body.
+// On requests, it must come after the option that sets the body
+func BodyRateLimit(bytesPerSec int64) BodyRateLimitOption {
+	return BodyRateLimitOption{bytesPerSec}
+}
+
+func (b BodyRateLimitOption) ModifyRequest(r *Request) error {
+	wrapBody(r, b.wrap)
+	return nil
+}
+
+func (b BodyRateLimitOption) ProcessResponse(resp *Response) error {
+	wrapResponseBody(resp, b.wrap)
+	return nil
+}
+
+func (b BodyRateLimitOption) wrap(rc io.ReadCloser) io.ReadCloser {
+	if b.bytesPerSec <= 0 {
+		return rc
+	}
+	return &throttledReader{ReadCloser: rc, bytesPerSec: b.bytesPerSec}
+}
+
+type throttledReader struct {
+	io.ReadCloser
+	bytesPerSec int64
+
+	start time.Time
+	read  int64
+}
+
+// Read reads at most a tenth of a second worth of data at a time,
+// sleeping as long as needed to keep the average rate under the limit
+func (t *throttledReader) Read(p []byte) (int, error) {
+	if t.start.IsZero() {
+		t.start = time.Now()
+	}
+
+	chunk := t.bytesPerSec / 10
+	if chunk < 1 {
+		chunk = 1
+	}
+	if int64(len(p)) > chunk {
+		p = p[:chunk]
+	}
+
+	n, err := t.ReadCloser.Read(p)
+	t.read += int64(n)
+
+	expected := time.Duration(float64(t.read) / float64(t.bytesPerSec) * float64(time.Second))
+	if wait := expected - time.Since(t.start); wait > 0 {
+		time.Sleep(wait)
+	}
+	return n, err
+}
@@ -0,0 +1,68 @@
+package http_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	stdhttp "net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/conradludgate/go-http"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBodyProgress(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterNoResponder(Echo)
+
+	body := bytes.Repeat([]byte("x"), 100000)
+
+	var sent, sentTotal, received, receivedTotal int64
+	resp, err := http.NewClient(http.URLString("https://example.com/upload")).Post(
+		http.Body(bytes.NewReader(body)),
+		http.BodyProgress(func(s, t int64) { sent, sentTotal = s, t }),
+	).Send(context.Background(),
+		http.BodyProgress(func(s, t int64) { received, receivedTotal = s, t }),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	assert.EqualValues(t, 100000, sent)
+	assert.EqualValues(t, 100000, sentTotal)
+	assert.EqualValues(t, 100000, received)
+	assert.EqualValues(t, 100000, receivedTotal)
+}
+
+func TestBodyRateLimit(t *testing.T) {
+	var uploaded int
+	server := httptest.NewServer(stdhttp.HandlerFunc(func(w stdhttp.ResponseWriter, r *stdhttp.Request) {
+		b, _ := io.ReadAll(r.Body)
+		uploaded = len(b)
+		_, _ = w.Write(bytes.Repeat([]byte("x"), 2000))
+	}))
+	defer server.Close()
+
+	client := http.NewClient(http.URLString(server.URL))
+
+	start := time.Now()
+	_, err := client.Post(
+		http.Body(bytes.NewReader(make([]byte, 2000))),
+		http.BodyRateLimit(10000),
+	).Send(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2000, uploaded)
+	assert.GreaterOrEqual(t, int64(time.Since(start)), int64(180*time.Millisecond))
+
+	start = time.Now()
+	resp, err := client.Get().Send(context.Background(), http.BodyRateLimit(10000), http.BodyProgress(func(s, t int64) {}))
+	require.NoError(t, err)
+	b, err := io.ReadAll(resp)
+	require.NoError(t, err)
+	assert.Len(t, b, 2000)
+	assert.GreaterOrEqual(t, int64(time.Since(start)), int64(180*time.Millisecond))
+}
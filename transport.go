@@ -0,0 +1,30 @@
+package http
+
+import (
+	stdhttp "net/http"
+)
+
+// Doer sends a single HTTP request, returning its response. *stdhttp.Client is a Doer
+type Doer interface {
+	Do(*stdhttp.Request) (*stdhttp.Response, error)
+}
+
+// DoerFunc is a function implementing Doer
+type DoerFunc func(*stdhttp.Request) (*stdhttp.Response, error)
+
+func (f DoerFunc) Do(req *stdhttp.Request) (*stdhttp.Response, error) {
+	return f(req)
+}
+
+// TransportMiddleware wraps the Doer that sends requests from a client, to inspect, modify or re-send
+// the underlying requests and responses. Requests given to a Doer can be re-sent if they have a GetBody function
+type TransportMiddleware func(next Doer) Doer
+
+// doer returns the base client wrapped in the transport middlewares of the client
+func (c *Client) doer() Doer {
+	var doer Doer = c.BaseClient()
+	for i := len(c.TransportMiddlewares) - 1; i >= 0; i-- {
+		doer = c.TransportMiddlewares[i](doer)
+	}
+	return doer
+}
@@ -1,20 +1,25 @@
 package http_test
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"net/http"
+	"io"
+	stdhttp "net/http"
 	"strings"
+	"testing"
 
+	"github.com/conradludgate/go-http"
 	"github.com/go-test/deep"
 	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/require"
 )
 
-type Verifier func(req *http.Request) error
+type Verifier func(req *stdhttp.Request) error
 
 func VerifyJSONBody(expected interface{}) Verifier {
-	return func(req *http.Request) error {
+	return func(req *stdhttp.Request) error {
 		if req.Body == nil {
 			return errors.New("no body")
 		}
@@ -47,7 +52,7 @@ func VerifyJSONBody(expected interface{}) Verifier {
 }
 
 func VerifyHeader(key string, values ...string) Verifier {
-	return func(req *http.Request) error {
+	return func(req *stdhttp.Request) error {
 		vs := req.Header.Values(key)
 		if len(vs) != len(values) {
 			return fmt.Errorf("invalid headers. expected %+v, got %+v", values, vs)
@@ -63,10 +68,10 @@ func VerifyHeader(key string, values ...string) Verifier {
 }
 
 func RespondWith(responder httpmock.Responder, verifiers ...Verifier) httpmock.Responder {
-	return func(req *http.Request) (*http.Response, error) {
+	return func(req *stdhttp.Request) (*stdhttp.Response, error) {
 		for _, verifier := range verifiers {
 			if err := verifier(req); err != nil {
-				return httpmock.NewStringResponse(http.StatusInternalServerError, err.Error()), nil
+				return httpmock.NewStringResponse(stdhttp.StatusInternalServerError, err.Error()), nil
 			}
 		}
 
@@ -75,7 +80,39 @@ func RespondWith(responder httpmock.Responder, verifiers ...Verifier) httpmock.R
 }
 
 func JSON(response interface{}) httpmock.Responder {
-	return func(req *http.Request) (*http.Response, error) {
-		return httpmock.NewJsonResponse(http.StatusOK, response)
+	return func(req *stdhttp.Request) (*stdhttp.Response, error) {
+		return httpmock.NewJsonResponse(stdhttp.StatusOK, response)
 	}
 }
+
+// Echo responds with the body and headers of the request,
+// adding the method and URI of the request as the X-Method and X-Request-Uri headers
+func Echo(req *stdhttp.Request) (*stdhttp.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		b, err := io.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		body = b
+	}
+
+	resp := httpmock.NewBytesResponse(stdhttp.StatusOK, body)
+	resp.ContentLength = int64(len(body))
+	for k, vs := range req.Header {
+		resp.Header[k] = append([]string(nil), vs...)
+	}
+	resp.Header.Set("X-Method", req.Method)
+	resp.Header.Set("X-Request-Uri", req.URL.RequestURI())
+	return resp, nil
+}
+
+// ReadBody sends the request, returning the response and its body
+func ReadBody(t *testing.T, req *http.Request, options ...http.ResponseOption) (*http.Response, string) {
+	t.Helper()
+	resp, err := req.Send(context.Background(), options...)
+	require.NoError(t, err)
+	b, err := io.ReadAll(resp)
+	require.NoError(t, err)
+	return resp, string(b)
+}
@@ -0,0 +1,171 @@
+package http
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+	"unicode/utf8"
+)
+
+// XMLCodec is the Codec for application/xml and text/xml bodies
+type XMLCodec struct{}
+
+func (XMLCodec) ContentTypes() []string {
+	return []string{"application/xml", "text/xml"}
+}
+
+func (XMLCodec) Encode(w io.Writer, v interface{}) error {
+	return xml.NewEncoder(w).Encode(v)
+}
+
+func (XMLCodec) Decode(r io.Reader, v interface{}) error {
+	dec := xml.NewDecoder(r)
+	dec.CharsetReader = charsetReader
+	return dec.Decode(v)
+}
+
+type XMLOption struct {
+	v       interface{}
+	prolog  bool
+	charset string
+}
+
+// XML is an option to add a XML Body to a request or to expect a XML Body in a response
+func XML(v interface{}) XMLOption {
+	return XMLOption{v: v}
+}
+
+// WithProlog returns a copy of the option that starts the request body with a XML declaration
+func (x XMLOption) WithProlog() XMLOption {
+	x.prolog = true
+	return x
+}
+
+// WithCharset returns a copy of the option that encodes the request body with the charset.
+// UTF-8, ISO-8859-1 and US-ASCII are supported
+func (x XMLOption) WithCharset(charset string) XMLOption {
+	x.charset = charset
+	return x
+}
+
+func (x XMLOption) ModifyRequest(r *Request) error {
+	b := bytes.NewBuffer(nil)
+	if x.prolog {
+		encoding := x.charset
+		if encoding == "" {
+			encoding = "UTF-8"
+		}
+		fmt.Fprintf(b, "<?xml version=\"1.0\" encoding=\"%s\"?>\n", encoding)
+	}
+	if err := xml.NewEncoder(b).Encode(x.v); err != nil {
+		return fmt.Errorf("cannot encode request body: %w", err)
+	}
+
+	ct := "application/xml"
+	if x.charset != "" {
+		encoded, err := encodeCharset(x.charset, b.Bytes())
+		if err != nil {
+			return fmt.Errorf("cannot encode request body: %w", err)
+		}
+		b = bytes.NewBuffer(encoded)
+		ct = mime.FormatMediaType(ct, map[string]string{"charset": x.charset})
+	}
+	return r.applyOptions(Body(b), SetHeader("Content-Type", ct))
+}
+
+func (x XMLOption) ProcessResponse(resp *Response) error {
+	var r io.Reader = resp.body
+	dec := xml.NewDecoder(r)
+	dec.CharsetReader = charsetReader
+
+	if ct := resp.Headers.Get("Content-Type"); ct != "" {
+		mt, params, _ := mime.ParseMediaType(ct)
+		if !matchMediaType("application/xml", mt) && mt != "text/xml" {
+			return fmt.Errorf("invalid Content-Type header, expected 'application/xml', got %s", ct)
+		}
+		// the charset of the Content-Type takes precedence over the XML declaration
+		if charset := params["charset"]; charset != "" {
+			cr, err := charsetReader(charset, r)
+			if err != nil {
+				return err
+			}
+			dec = xml.NewDecoder(cr)
+			dec.CharsetReader = func(_ string, input io.Reader) (io.Reader, error) {
+				return input, nil
+			}
+		}
+	}
+	return dec.Decode(x.v)
+}
+
+func isLatin1(charset string) bool {
+	switch strings.ToLower(charset) {
+	case "iso-8859-1", "latin1", "us-ascii", "ascii":
+		return true
+	}
+	return false
+}
+
+// charsetReader converts input in the charset into UTF-8
+func charsetReader(charset string, input io.Reader) (io.Reader, error) {
+	if strings.EqualFold(charset, "utf-8") || strings.EqualFold(charset, "utf8") {
+		return input, nil
+	}
+	if isLatin1(charset) {
+		return &latin1Reader{r: bufio.NewReader(input)}, nil
+	}
+	return nil, fmt.Errorf("unsupported charset %s", charset)
+}
+
+// encodeCharset converts UTF-8 encoded text into the charset
+func encodeCharset(charset string, b []byte) ([]byte, error) {
+	if strings.EqualFold(charset, "utf-8") || strings.EqualFold(charset, "utf8") {
+		return b, nil
+	}
+	if !isLatin1(charset) {
+		return nil, fmt.Errorf("unsupported charset %s", charset)
+	}
+	max := rune(0xFF)
+	if strings.Contains(strings.ToLower(charset), "ascii") {
+		max = 0x7F
+	}
+	out := make([]byte, 0, len(b))
+	for _, r := range string(b) {
+		if r > max {
+			return nil, fmt.Errorf("character %q cannot be encoded in %s", r, charset)
+		}
+		out = append(out, byte(r))
+	}
+	return out, nil
+}
+
+type latin1Reader struct {
+	r   *bufio.Reader
+	buf []byte
+}
+
+// Read converts each ISO-8859-1 byte into its UTF-8 encoding
+func (l *latin1Reader) Read(p []byte) (int, error) {
+	var rb [utf8.UTFMax]byte
+	for len(l.buf) < len(p) {
+		if len(l.buf) > 0 && l.r.Buffered() == 0 {
+			break // don't block when there is already something to return
+		}
+		b, err := l.r.ReadByte()
+		if err != nil {
+			if len(l.buf) > 0 {
+				break
+			}
+			return 0, err
+		}
+		n := utf8.EncodeRune(rb[:], rune(b))
+		l.buf = append(l.buf, rb[:n]...)
+	}
+	n := copy(p, l.buf)
+	l.buf = l.buf[n:]
+	return n, nil
+}
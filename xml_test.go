@@ -0,0 +1,76 @@
+package http_test
+
+import (
+	"context"
+	"encoding/xml"
+	"io"
+	stdhttp "net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/conradludgate/go-http"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type Envelope struct {
+	XMLName xml.Name `xml:"Envelope"`
+	Name    string   `xml:"Body>Name"`
+}
+
+func TestXML(t *testing.T) {
+	var received []byte
+	server := httptest.NewServer(stdhttp.HandlerFunc(func(w stdhttp.ResponseWriter, r *stdhttp.Request) {
+		received, _ = io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", r.Header.Get("Content-Type"))
+		_, _ = w.Write(received)
+	}))
+	defer server.Close()
+
+	client := http.NewClient(http.URLString(server.URL))
+	ctx := context.Background()
+
+	var respBody Envelope
+	resp, err := client.Post(http.XML(Envelope{Name: "café"})).Send(ctx, http.XML(&respBody))
+	require.NoError(t, err)
+	assert.Equal(t, "application/xml", resp.Headers.Get("Content-Type"))
+	assert.Equal(t, "<Envelope><Body><Name>café</Name></Body></Envelope>", string(received))
+	assert.Equal(t, "café", respBody.Name)
+
+	respBody = Envelope{}
+	resp, err = client.Post(
+		http.XML(Envelope{Name: "café"}).WithProlog().WithCharset("ISO-8859-1"),
+	).Send(ctx, http.XML(&respBody))
+	require.NoError(t, err)
+	assert.Equal(t, "application/xml; charset=ISO-8859-1", resp.Headers.Get("Content-Type"))
+	assert.Equal(t, "<?xml version=\"1.0\" encoding=\"ISO-8859-1\"?>\n<Envelope><Body><Name>caf\xe9</Name></Body></Envelope>", string(received))
+	assert.Equal(t, "café", respBody.Name)
+
+	// the XML codec is registered by default
+	respBody = Envelope{}
+	resp, err = client.Post(
+		http.SetHeader("Accept", "text/xml"),
+		http.Encode(Envelope{Name: "codec"}),
+	).Send(ctx, http.Decode(&respBody))
+	require.NoError(t, err)
+	assert.Equal(t, "text/xml", resp.Headers.Get("Content-Type"))
+	assert.Equal(t, "codec", respBody.Name)
+}
+
+func TestXML_Errors(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "https://example.com/json", httpmock.NewJsonResponderOrPanic(200, FooBar{}))
+
+	client := http.NewClient(http.URLString("https://example.com"))
+	ctx := context.Background()
+
+	var respBody Envelope
+	_, err := client.Get(http.Path("json")).Send(ctx, http.XML(&respBody))
+	assert.EqualError(t, err, "invalid Content-Type header, expected 'application/xml', got application/json")
+
+	err = client.Post(http.XML(Envelope{Name: "日本"}).WithCharset("us-ascii")).Error()
+	assert.EqualError(t, err, "cannot encode request body: character '日' cannot be encoded in us-ascii")
+}